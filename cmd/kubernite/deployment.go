@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	kuberniteConfig "kubernite/configs/kubernite"
+	"kubernite/internal/pkg/kustomize"
+	kubernetesManifest "kubernite/pkg/kubernetes/manifest"
+)
+
+/*
+deployment is the object to deploy and the file which records it. In kustomize mode the
+object is rendered from the kustomization, which is the file written and committed
+instead of the deployment file. In helm mode there is no object to deploy, only the
+values file which is written and committed.
+*/
+type deployment struct {
+	manifestFile  *kubernetesManifest.Manifest
+	kustomization *kubernetesManifest.Kustomization
+	values        *kubernetesManifest.Values
+}
+
+/*
+openDeployment finds the object to deploy in the deployment file. If a kustomization path
+is given the image tag is set in the kustomization which is rendered to find the object.
+If a helm values file path is given the image tag is set in the values file.
+*/
+func openDeployment(kuberniteConf *kuberniteConfig.Config, imageTag string) (*deployment, error) {
+	if kuberniteConf.HelmValuesFilePath != "" {
+		values, err := kubernetesManifest.NewValuesFromFile(kuberniteConf.HelmValuesFilePath)
+		if err != nil {
+			return nil, err
+		}
+		if err := values.SetStringField(kuberniteConf.HelmValuesTagPath, imageTag); err != nil {
+			return nil, err
+		}
+		log.Info(fmt.Sprintf("updated helm value %s to %s", kuberniteConf.HelmValuesTagPath, imageTag))
+		return &deployment{values: values}, nil
+	}
+
+	if kuberniteConf.KustomizationPath == "" {
+		manifestFile, err := kubernetesManifest.NewManifestFromFile(
+			kuberniteConf.DeploymentFilePath,
+			kuberniteConf.DeploymentKind,
+			kuberniteConf.DeploymentName,
+		)
+		if err != nil {
+			return nil, err
+		}
+		return &deployment{manifestFile: manifestFile}, nil
+	}
+
+	kustomization, err := kubernetesManifest.NewKustomizationFromFile(kuberniteConf.KustomizationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// update the images entries of the kustomization
+	imageUpdates, err := getImageUpdates(kuberniteConf, imageTag)
+	if err != nil {
+		return nil, err
+	}
+	for _, imageUpdate := range imageUpdates {
+		if imageUpdate.ContainerName != "" || imageUpdate.ImagePattern != "" || imageUpdate.MarkerKey != "" {
+			return nil, fmt.Errorf("only images can be selected by name in kustomize mode, not by %s", imageUpdate)
+		}
+		image, err := kustomization.UpdateImage(imageUpdate.ImageName, kuberniteConf.KustomizationImageNewName, imageUpdate.Tag)
+		if err != nil {
+			return nil, err
+		}
+		log.Info(fmt.Sprintf(
+			"updated kustomization image %s: newName '%s', newTag '%s', digest '%s'",
+			image.Name, image.NewName, image.NewTag, image.Digest,
+		))
+	}
+
+	// render the overlay and find the object to deploy in it
+	renderedData, err := kustomize.Build(kuberniteConf.KustomizeBinaryPath, kustomization)
+	if err != nil {
+		return nil, err
+	}
+	manifestFile, err := kubernetesManifest.NewManifestFromData(
+		renderedData,
+		kustomization.PathToFile,
+		kuberniteConf.DeploymentKind,
+		kuberniteConf.DeploymentName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	// the images were set by kustomize, so they are recorded for conflicting updates to be retried with them
+	if workload, isWorkload := manifestFile.Object.(kubernetesManifest.Workload); isWorkload {
+		workload.RecordImages()
+	}
+
+	return &deployment{
+		manifestFile:  manifestFile,
+		kustomization: kustomization,
+	}, nil
+}
+
+// filePath returns the path of the file which records the deployment
+func (d *deployment) filePath(kuberniteConf *kuberniteConfig.Config) string {
+	if d.values != nil {
+		return d.values.PathToFile
+	}
+	if d.kustomization != nil {
+		return d.kustomization.PathToFile
+	}
+	return kuberniteConf.DeploymentFilePath
+}
+
+// yaml returns the updated deployment file, kustomization or values file as it will be written
+func (d *deployment) yaml() ([]byte, error) {
+	if d.values != nil {
+		return d.values.YAML()
+	}
+	if d.kustomization != nil {
+		return d.kustomization.YAML()
+	}
+	return d.manifestFile.YAML()
+}
+
+// write writes the updated deployment file, kustomization or values file
+func (d *deployment) write(kuberniteConf *kuberniteConfig.Config) error {
+	if d.values != nil {
+		return d.values.WriteToYAML()
+	}
+	if d.kustomization != nil {
+		return d.kustomization.WriteToYAML()
+	}
+	return d.manifestFile.WriteToYAMLAtPath(kuberniteConf.DeploymentFilePath)
+}
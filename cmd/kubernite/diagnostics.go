@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"io/ioutil"
+	kuberniteConfig "kubernite/configs/kubernite"
+	kubernetesClient "kubernite/internal/pkg/kubernetes/client"
+	kubernetesManifest "kubernite/pkg/kubernetes/manifest"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+reportRolloutDiagnostics reports the events and logs explaining a failed rollout of the
+workload. The build fails either way so errors are only logged.
+*/
+func reportRolloutDiagnostics(
+	kuberniteConf *kuberniteConfig.Config,
+	kubeClient *kubernetesClient.Client,
+	workload kubernetesManifest.Workload,
+) {
+	diagnostics, err := kubeClient.CollectDiagnostics(workload, kuberniteConf.DiagnosticsLogLines)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	reportDiagnostics(kuberniteConf, diagnostics)
+}
+
+/*
+reportApplyDiagnostics reports the events of the object after applying it failed. The
+build fails either way so errors are only logged.
+*/
+func reportApplyDiagnostics(
+	kuberniteConf *kuberniteConfig.Config,
+	kubeClient *kubernetesClient.Client,
+	object kubernetesManifest.Object,
+) {
+	diagnostics, err := kubeClient.CollectObjectDiagnostics(object)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	reportDiagnostics(kuberniteConf, diagnostics)
+}
+
+/*
+reportDiagnostics prints the diagnostics grouped per pod and writes them to the
+diagnostics directory, one file for the object and one per pod, for later pipeline steps
+*/
+func reportDiagnostics(kuberniteConf *kuberniteConfig.Config, diagnostics *kubernetesClient.Diagnostics) {
+	files := map[string]string{
+		fmt.Sprintf("%s-%s.log", strings.ToLower(diagnostics.Kind), diagnostics.Name): diagnostics.String(),
+	}
+	fmt.Print(diagnostics.String())
+	for _, podDiagnostics := range diagnostics.Pods {
+		files[fmt.Sprintf("pod-%s.log", podDiagnostics.Name)] = podDiagnostics.String()
+		fmt.Print(podDiagnostics.String())
+	}
+
+	if kuberniteConf.DiagnosticsDirPath == "" {
+		return
+	}
+	if err := os.MkdirAll(kuberniteConf.DiagnosticsDirPath, 0755); err != nil {
+		log.Error(fmt.Sprintf("error creating diagnostics directory '%s': %s", kuberniteConf.DiagnosticsDirPath, err))
+		return
+	}
+	for fileName, content := range files {
+		filePath := filepath.Join(kuberniteConf.DiagnosticsDirPath, fileName)
+		if err := ioutil.WriteFile(filePath, []byte(content), 0644); err != nil {
+			log.Error(fmt.Sprintf("error writing diagnostics to '%s': %s", filePath, err))
+			return
+		}
+	}
+	log.Info(fmt.Sprintf("diagnostics written to %s", kuberniteConf.DiagnosticsDirPath))
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/ssh/terminal"
+	"io/ioutil"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kuberniteConfig "kubernite/configs/kubernite"
+	"kubernite/internal/pkg/diff"
+	kubernetesClient "kubernite/internal/pkg/kubernetes/client"
+	kubernetesManifest "kubernite/pkg/kubernetes/manifest"
+	"os"
+	"sigs.k8s.io/yaml"
+	"strings"
+)
+
+/*
+dryRun prints the unified diff between the deployment file as it was read and as it would
+be written and, if set, between the live object in the cluster and the object which would
+be applied. On a server dry run the update is also sent to the API server, which reports
+whether it would be admitted and the object with server defaults.
+*/
+func dryRun(kuberniteConf *kuberniteConfig.Config, deploy *deployment) error {
+	var diffs []string
+
+	// diff the deployment file
+	deploymentFilePath := deploy.filePath(kuberniteConf)
+	originalData, err := ioutil.ReadFile(deploymentFilePath)
+	if err != nil {
+		return fmt.Errorf("error reading '%s' to diff: %s", deploymentFilePath, err)
+	}
+	updatedData, err := deploy.yaml()
+	if err != nil {
+		return err
+	}
+	diffPath := strings.TrimPrefix(deploymentFilePath, "/")
+	fileDiff, err := diff.Unified("a/"+diffPath, "b/"+diffPath, originalData, updatedData)
+	if err != nil {
+		return err
+	}
+	if fileDiff == "" {
+		log.Info(fmt.Sprintf("%s would not change", deploymentFilePath))
+	}
+	diffs = append(diffs, fileDiff)
+
+	if deploy.kustomization != nil {
+		log.Info(fmt.Sprintf("kubectl apply -k %s", deploy.kustomization.Dir()))
+		log.Info(fmt.Sprintf("rendered overlay:\n%s", deploy.manifestFile.String()))
+	} else if deploy.manifestFile != nil {
+		log.Info(fmt.Sprintf("kubectl apply -f %s", kuberniteConf.DeploymentFilePath))
+	}
+
+	// there is no object to send to the cluster when updating a helm values file
+	if deploy.manifestFile == nil {
+		return printDiffs(kuberniteConf, diffs)
+	}
+	desiredObject := deploy.manifestFile.Object
+	desiredYAML, err := desiredObject.YAML()
+	if err != nil {
+		return err
+	}
+	var desiredContent map[string]interface{}
+	if err := yaml.Unmarshal(desiredYAML, &desiredContent); err != nil {
+		return fmt.Errorf("error decoding desired object: %s", err)
+	}
+
+	var kubeClient *kubernetesClient.Client
+	if kuberniteConf.DryRunLevel == kuberniteConfig.ServerDryRun || kuberniteConf.DryRunLiveDiff {
+		if kubeClient, err = kubernetesClient.NewClientFromKuberniteConfig(kuberniteConf); err != nil {
+			return err
+		}
+	}
+
+	// send the update to the api server without persisting it
+	if kuberniteConf.DryRunLevel == kuberniteConfig.ServerDryRun {
+		var serverObject *unstructured.Unstructured
+		if kuberniteConf.ApplyStrategy == kuberniteConfig.ServerSideApplyStrategy {
+			serverObject, err = kubeClient.ServerSideApply(desiredObject, kuberniteConf.ApplyForceConflicts, true)
+		} else {
+			serverObject, err = kubeClient.DryRunObject(desiredObject)
+		}
+		if err != nil {
+			// still show what would have changed
+			if diffErr := printDiffs(kuberniteConf, diffs); diffErr != nil {
+				log.Error(diffErr)
+			}
+			return err
+		}
+		log.Info(fmt.Sprintf(
+			"server dry run of %s '%s' passed authentication, authorisation, admission and validation",
+			serverObject.GetKind(),
+			desiredObject.GetName(),
+		))
+		serverData, err := diff.ObjectYAML(serverObject.UnstructuredContent())
+		if err != nil {
+			return err
+		}
+		log.Info(fmt.Sprintf("object with server defaults:\n%s", serverData))
+
+		// compare the live object with the object as the server would store it
+		desiredContent = serverObject.UnstructuredContent()
+	}
+
+	if kuberniteConf.DryRunLiveDiff {
+		liveDiff, err := diffLiveObject(kubeClient, desiredObject, desiredContent)
+		if err != nil {
+			return err
+		}
+		diffs = append(diffs, liveDiff)
+	}
+
+	return printDiffs(kuberniteConf, diffs)
+}
+
+/*
+printDiffs prints the given diffs, colourised if printed to a terminal, and writes them as
+plain text to settings.dry_run_diff_file_path if it is set
+*/
+func printDiffs(kuberniteConf *kuberniteConfig.Config, diffs []string) error {
+	unifiedDiff := strings.Join(diffs, "")
+	if terminal.IsTerminal(int(os.Stdout.Fd())) {
+		fmt.Print(diff.Colourise(unifiedDiff))
+	} else {
+		fmt.Print(unifiedDiff)
+	}
+
+	if kuberniteConf.DryRunDiffFilePath != "" {
+		if err := ioutil.WriteFile(kuberniteConf.DryRunDiffFilePath, []byte(unifiedDiff), 0644); err != nil {
+			return fmt.Errorf("error writing diff to '%s': %s", kuberniteConf.DryRunDiffFilePath, err)
+		}
+		log.Info(fmt.Sprintf("diff written to %s", kuberniteConf.DryRunDiffFilePath))
+	}
+
+	return nil
+}
+
+// diffLiveObject diffs the live object in the cluster, or nothing if it does not exist, against the given desired object content
+func diffLiveObject(
+	kubeClient *kubernetesClient.Client,
+	desiredObject kubernetesManifest.Object,
+	desiredContent map[string]interface{},
+) (string, error) {
+	objectName := fmt.Sprintf("%s/%s", desiredObject.GetObjectKind().GroupVersionKind().Kind, desiredObject.GetName())
+
+	// an object which does not exist yet is diffed against an empty live object
+	exists, err := kubeClient.ObjectExists(desiredObject)
+	if err != nil {
+		return "", err
+	}
+	var liveData []byte
+	if exists {
+		liveObject, err := kubeClient.GetLiveObject(desiredObject)
+		if err != nil {
+			return "", err
+		}
+		liveData, err = diff.ObjectYAML(liveObject.UnstructuredContent())
+		if err != nil {
+			return "", err
+		}
+	} else {
+		log.Info(fmt.Sprintf("live %s does not exist and would be created", objectName))
+	}
+	desiredData, err := diff.ObjectYAML(desiredContent)
+	if err != nil {
+		return "", err
+	}
+
+	liveDiff, err := diff.Unified("live/"+objectName, "desired/"+objectName, liveData, desiredData)
+	if err != nil {
+		return "", err
+	}
+	if liveDiff == "" {
+		log.Info(fmt.Sprintf("live %s would not change", objectName))
+	}
+	return liveDiff, nil
+}
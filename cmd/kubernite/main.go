@@ -1,12 +1,17 @@
 package main
 
 import (
+	"bytes"
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	kuberniteConfig "kubernite/configs/kubernite"
 	kubernetesClient "kubernite/internal/pkg/kubernetes/client"
+	"kubernite/internal/pkg/tag"
 	"kubernite/pkg/git"
 	kubernetesManifest "kubernite/pkg/kubernetes/manifest"
+	"sort"
+	"strings"
+	"text/template"
 	"time"
 )
 
@@ -17,165 +22,438 @@ func main() {
 		log.Fatal(err)
 	}
 
+	// skip build events which are not to be deployed
+	if kuberniteConf.EventAction() == kuberniteConfig.SkipEventAction {
+		log.Info(fmt.Sprintf("skipping %s event", kuberniteConf.BuildEvent))
+		return
+	}
+
 	// handle build event
-	deploymentFile, err := handleDeployment(kuberniteConf)
+	deploy, err := handleDeployment(kuberniteConf)
 	if err != nil {
 		log.Fatal(err)
 	}
+	manifestFile := deploy.manifestFile
 
-	// if this is a dry run, print out deployment file to be updated
+	// if this is a dry run, print out the changes to the deployment file
 	if kuberniteConf.DryRun {
 		log.Info(fmt.Sprintf("____%s event dry run____", kuberniteConf.BuildEvent))
-		log.Info(fmt.Sprintf("kubectl apply -f %s", kuberniteConf.DeploymentFilePath))
-		log.Info(fmt.Sprintf("\n%s", deploymentFile.String()))
+		if err := dryRun(kuberniteConf, deploy); err != nil {
+			log.Fatal(err)
+		}
 		return
 	}
 
-	// create a kubernetes client
-	kubeClient, err := kubernetesClient.NewClientFromKuberniteConfig(kuberniteConf)
-	if err != nil {
-		log.Fatal(err)
-	}
+	// apply the object, charts are rendered and applied from the committed values file instead
+	if manifestFile != nil {
+		// create a kubernetes client
+		kubeClient, err := kubernetesClient.NewClientFromKuberniteConfig(kuberniteConf)
+		if err != nil {
+			log.Fatal(err)
+		}
 
-	// apply the deployment
-	deploymentClient := kubeClient.Clientset.AppsV1().Deployments(deploymentFile.Namespace)
-	if _, err := deploymentClient.Update(deploymentFile.Deployment); err != nil {
-		log.Fatal(err)
+		// objects which do not exist yet are created with the initial deploy as their change cause
+		exists, err := kubeClient.ObjectExists(manifestFile.Object)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if !exists {
+			log.Info(fmt.Sprintf("%s does not exist and will be created", manifestFile.Object.GetName()))
+			if err := setChangeCause(manifestFile, initialDeployChangeCause); err != nil {
+				log.Fatal(err)
+			}
+		}
+
+		if err := applyObject(kuberniteConf, kubeClient, manifestFile.Object); err != nil {
+			reportApplyDiagnostics(kuberniteConf, kubeClient, manifestFile.Object)
+			log.Fatal(err)
+		}
+
+		// wait for the rollout so that a failed rollout fails the build
+		if kuberniteConf.RolloutWait && manifestFile.Workload != nil {
+			if err := kubeClient.WaitForRollout(manifestFile.Workload, kuberniteConf.RolloutTimeout); err != nil {
+				log.Error(err)
+				reportRolloutDiagnostics(kuberniteConf, kubeClient, manifestFile.Workload)
+				if kuberniteConf.RolloutRollback {
+					rollback(kubeClient, manifestFile.Workload)
+				}
+				log.Fatal("rollout failed, not committing the deployment file")
+			}
+		}
 	}
 
 	// write file
-	if err := deploymentFile.WriteToYAMLAtPath(kuberniteConf.DeploymentFilePath); err != nil {
+	if err := deploy.write(kuberniteConf); err != nil {
 		log.Fatal(err)
 	}
 
 	//commit deployment if set
 	if kuberniteConf.CommitDeployment {
-		if err := commitDeployment(kuberniteConf); err != nil {
+		if err := commitDeployment(kuberniteConf, deploy.filePath(kuberniteConf)); err != nil {
 			log.Fatal(err)
 		}
 	}
 }
 
-func commitDeployment(kuberniteConf *kuberniteConfig.Config) error {
+/*
+applyObject applies the object with the configured apply strategy. Conflicts with other
+field managers are logged with the manager and field of each conflict, followed by a
+summary naming the competing managers.
+*/
+func applyObject(
+	kuberniteConf *kuberniteConfig.Config,
+	kubeClient *kubernetesClient.Client,
+	object kubernetesManifest.Object,
+) error {
+	if kuberniteConf.ApplyStrategy != kuberniteConfig.ServerSideApplyStrategy {
+		return kubeClient.ApplyObject(object)
+	}
+
+	_, err := kubeClient.ServerSideApply(object, kuberniteConf.ApplyForceConflicts, false)
+	if conflictErr, isConflict := err.(kubernetesClient.ErrApplyConflict); isConflict {
+		for _, conflict := range conflictErr.Conflicts {
+			log.WithFields(log.Fields{
+				"manager": conflict.Manager,
+				"field":   conflict.Field,
+			}).Error(conflict.Message)
+		}
+		log.Error(fmt.Sprintf(
+			"%d fields of %s '%s' are managed by [%s]",
+			len(conflictErr.Conflicts),
+			conflictErr.Kind,
+			conflictErr.Name,
+			strings.Join(conflictErr.Managers(), ", "),
+		))
+	}
+	return err
+}
+
+/*
+rollback rolls the workload back to its previous revision after a failed rollout. The
+build fails either way so an error rolling back is only logged.
+*/
+func rollback(kubeClient *kubernetesClient.Client, workload kubernetesManifest.Workload) {
+	revision, err := kubeClient.RollbackDeployment(workload)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	log.Info(fmt.Sprintf("rolled back %s to revision %d", workload.GetName(), revision))
+}
+
+func commitDeployment(kuberniteConf *kuberniteConfig.Config, deploymentFilePath string) error {
 	gitRepo, err := git.NewRepositoryFromFilePath(kuberniteConf.DeploymentFileRepositoryPath)
 	if err != nil {
 		return err
 	}
 	err = gitRepo.CommitDeployment(
 		kuberniteConf.DeploymentFileRepositoryPath,
-		kuberniteConf.DeploymentFilePath,
-		//kuberniteConf.GitUsername,
-		//kuberniteConf.GitPassword,
-		//kuberniteConf.GitKey
-		)
+		deploymentFilePath,
+		kuberniteConf.GitRemoteName,
+		kuberniteConf.GitBranch,
+		kuberniteConf.GitUsername,
+		kuberniteConf.GitPassword,
+		kuberniteConf.GitKey,
+		kuberniteConf.GitKnownHosts,
+	)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func handleDeployment(kuberniteConf *kuberniteConfig.Config) (*kubernetesManifest.Deployment, error) {
+func handleDeployment(kuberniteConf *kuberniteConfig.Config) (*deployment, error) {
 	switch kuberniteConf.BuildEvent {
 	case git.TagEvent:
 		return updateDeploymentForTagEvent(kuberniteConf)
-	default:
+	case git.PushEvent,
+		git.PullRequestEvent,
+		git.PromoteEvent,
+		git.RollbackEvent,
+		git.CronEvent,
+		git.CustomEvent:
 		return updateDeploymentForOtherEvent(kuberniteConf)
+	default:
+		return nil, fmt.Errorf("unsupported build event '%s'", kuberniteConf.BuildEvent)
 	}
 }
 
-func updateDeploymentForTagEvent(kuberniteConf *kuberniteConfig.Config) (*kubernetesManifest.Deployment, error) {
-	// open git repository
-	gitRepo, err := git.NewRepositoryFromFilePath(kuberniteConf.DeploymentTagRepositoryPath)
-	if err != nil {
-		return nil, err
+func updateDeploymentForTagEvent(kuberniteConf *kuberniteConfig.Config) (*deployment, error) {
+	// get the tag on the commit which triggered the build or from the tags file
+	var latestTag string
+	var err error
+	if kuberniteConf.DeploymentImageTagSource == tag.FileSource {
+		latestTag, err = getTagsFileTag(kuberniteConf)
+	} else {
+		latestTag, err = getTagEventTag(kuberniteConf)
 	}
-
-	// get the latest git tag on the repository
-	latestTag, err := gitRepo.GetLatestTagName()
 	if err != nil {
 		return nil, err
 	}
 
-	// open deployment file
-	deploymentFile, err := kubernetesManifest.NewDeploymentFromFile(kuberniteConf.DeploymentFilePath)
+	// open deployment file and find the object to deploy in it
+	deploy, err := openDeployment(kuberniteConf, latestTag)
 	if err != nil {
 		return nil, err
 	}
 
 	// update deployment file annotations with tag and event information
-	if err := deploymentFile.UpdateAnnotations(
-		"kubernetes.io/change-cause",
-		fmt.Sprintf(
-			"kubernite handled tag event @ %s - image updated to %s",
-			time.Now().Format("Jan-02-2006 15:04:05"),
-			latestTag,
-		),
-	); err != nil {
-		log.Fatal(err)
+	changeCause := fmt.Sprintf(
+		"kubernite handled tag event @ %s - image updated to %s",
+		time.Now().Format("Jan-02-2006 15:04:05"),
+		latestTag,
+	)
+	if err := updateManifest(kuberniteConf, deploy, latestTag, changeCause); err != nil {
+		return nil, err
 	}
-	if err := deploymentFile.UpdatePodTemplateAnnotations(
-		"kubernetes.io/change-cause",
-		fmt.Sprintf(
-			"kubernite handled tag event @ %s - image updated to %s",
-			time.Now().Format("Jan-02-2006 15:04:05"),
-			latestTag,
-		),
-	); err != nil {
-		log.Fatal(err)
+
+	return deploy, nil
+}
+
+func getTagsFileTag(kuberniteConf *kuberniteConfig.Config) (string, error) {
+	tags, err := tag.ReadTagsFile(kuberniteConf.DeploymentImageTagsFilePath)
+	if err != nil {
+		return "", err
 	}
+	return tag.Select(tags, kuberniteConf.DeploymentImageTagsFileRule)
+}
 
-	if err := deploymentFile.UpdateImageTag(kuberniteConf.DeploymentImageName, latestTag); err != nil {
-		log.Fatal(err)
+func getTagEventTag(kuberniteConf *kuberniteConfig.Config) (string, error) {
+	// open git repository
+	gitRepo, err := git.NewRepositoryFromFilePath(kuberniteConf.DeploymentTagRepositoryPath)
+	if err != nil {
+		return "", err
 	}
 
-	return deploymentFile, nil
+	// get the tags pointing at HEAD
+	headTags, err := gitRepo.GetHeadTags(
+		kuberniteConf.DeploymentTagPrefix,
+		kuberniteConf.DeploymentTagPattern,
+	)
+	switch err.(type) {
+	case nil:
+		// prefer the tag which triggered the build if there is more than one
+		for _, headTag := range headTags {
+			if headTag.Name().Short() == kuberniteConf.DroneTag {
+				return kuberniteConf.DroneTag, nil
+			}
+		}
+		return headTags[len(headTags)-1].Name().Short(), nil
+	case git.ErrNoHeadTag:
+		if kuberniteConf.DroneTag != "" {
+			log.Warn(fmt.Sprintf("%s, using DRONE_TAG %s", err, kuberniteConf.DroneTag))
+			return kuberniteConf.DroneTag, nil
+		}
+		return "", err
+	default:
+		return "", err
+	}
 }
 
-func updateDeploymentForOtherEvent(kuberniteConf *kuberniteConfig.Config) (*kubernetesManifest.Deployment, error) {
+func updateDeploymentForOtherEvent(kuberniteConf *kuberniteConfig.Config) (*deployment, error) {
 	// open git repository
 	gitRepo, err := git.NewRepositoryFromFilePath(kuberniteConf.DeploymentTagRepositoryPath)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	// get the latest commit hash in the repository
-	latestCommitHash, err := gitRepo.GetLatestCommitHash()
+	// get the latest commit in the repository
+	latestCommit, err := gitRepo.GetLatestCommit(kuberniteConf.DeploymentCommitRevision)
 	if err != nil {
 		return nil, err
 	}
 
-	// open deployment file
-	deploymentFile, err := kubernetesManifest.NewDeploymentFromFile(kuberniteConf.DeploymentFilePath)
+	// get the image tag from the tags file or render it
+	var imageTag string
+	if kuberniteConf.DeploymentImageTagSource == tag.FileSource {
+		imageTag, err = getTagsFileTag(kuberniteConf)
+	} else {
+		imageTag, err = tag.Render(
+			kuberniteConf.DeploymentImageTagStrategy,
+			kuberniteConf.DeploymentImageTagTemplate,
+			tag.Values{
+				Event:          kuberniteConf.BuildEvent.String(),
+				CommitSHA:      latestCommit.Hash.String(),
+				ShortCommitSHA: latestCommit.ShortHash(),
+				Branch:         kuberniteConf.DroneBranch,
+				BuildNumber:    kuberniteConf.DroneBuildNumber,
+			},
+		)
+	}
 	if err != nil {
 		return nil, err
 	}
 
-	// update deployment file annotations with tag and event information
-	if err := deploymentFile.UpdateAnnotations(
-		"kubernetes.io/change-cause",
-		fmt.Sprintf(
-			"kubernite handled %s event @ %s - commit hash %s",
-			kuberniteConf.BuildEvent,
-			time.Now().Format("Jan-02-2006 15:04:05"),
-			latestCommitHash,
-		),
-	); err != nil {
+	// open deployment file and find the object to deploy in it
+	deploy, err := openDeployment(kuberniteConf, imageTag)
+	if err != nil {
 		return nil, err
 	}
-	if err := deploymentFile.UpdatePodTemplateAnnotations(
-		"kubernetes.io/change-cause",
-		fmt.Sprintf(
-			"kubernite handled %s event @ %s - commit hash %s",
-			kuberniteConf.BuildEvent,
-			time.Now().Format("Jan-02-2006 15:04:05"),
-			latestCommitHash,
-		),
-	); err != nil {
+
+	// update deployment file annotations with commit and event information
+	changeCause := fmt.Sprintf(
+		"kubernite handled %s event @ %s - image updated to %s - commit %s '%s' authored by %s, committed by %s",
+		kuberniteConf.BuildEvent,
+		time.Now().Format("Jan-02-2006 15:04:05"),
+		imageTag,
+		latestCommit.ShortHash(),
+		latestCommit.Subject(),
+		latestCommit.AuthorName(),
+		latestCommit.CommitterName(),
+	)
+	if deploy.manifestFile != nil {
+		if err := deploy.manifestFile.Object.UpdateAnnotations("kubernite/commit-hash", latestCommit.Hash.String()); err != nil {
+			return nil, err
+		}
+	}
+	if err := updateManifest(kuberniteConf, deploy, imageTag, changeCause); err != nil {
 		return nil, err
 	}
-	if err := deploymentFile.UpdateImageTag(kuberniteConf.DeploymentImageName, "latest"); err != nil {
-		log.Fatal(err)
+
+	return deploy, nil
+}
+
+/*
+updateManifest records the change cause and deploys the image tag. Workloads have the
+image tag set on their containers, unless the images were already set by kustomize, any
+other kind of object only has the configured deployment fields set. Helm values files have
+no annotations so only the configured deployment fields are set.
+*/
+func updateManifest(
+	kuberniteConf *kuberniteConfig.Config,
+	deploy *deployment,
+	imageTag string,
+	changeCause string,
+) error {
+	if deploy.values != nil {
+		return setDeploymentFields(kuberniteConf, deploy.values, imageTag)
+	}
+
+	manifestFile := deploy.manifestFile
+	if err := setChangeCause(manifestFile, changeCause); err != nil {
+		return err
+	}
+	if deploymentFile := manifestFile.Workload; deploymentFile != nil && deploy.kustomization == nil {
+		imageUpdates, err := getImageUpdates(kuberniteConf, imageTag)
+		if err != nil {
+			return err
+		}
+		containerUpdates, err := deploymentFile.UpdateImageTags(imageUpdates)
+		if err != nil {
+			return err
+		}
+		if len(containerUpdates) == 0 {
+			log.Info("no container images changed")
+		}
+		for _, containerUpdate := range containerUpdates {
+			log.Info(fmt.Sprintf("updated %s", containerUpdate))
+		}
+	} else if manifestFile.Workload == nil && len(kuberniteConf.DeploymentFields) == 0 {
+		log.Warn(fmt.Sprintf(
+			"%s %s is not a workload and no deployment fields are set, only its annotations are updated",
+			manifestFile.Object.GetObjectKind().GroupVersionKind().Kind,
+			manifestFile.Object.GetName(),
+		))
+	}
+
+	return setDeploymentFields(kuberniteConf, manifestFile.Object, imageTag)
+}
+
+// initialDeployChangeCause is the change cause of objects created by kubernite
+const initialDeployChangeCause = "initial deploy"
+
+// setChangeCause sets the change cause annotation of the object and of the pod template of workloads
+func setChangeCause(manifestFile *kubernetesManifest.Manifest, changeCause string) error {
+	if err := manifestFile.Object.UpdateAnnotations("kubernetes.io/change-cause", changeCause); err != nil {
+		return err
 	}
+	if deploymentFile := manifestFile.Workload; deploymentFile != nil {
+		if err := deploymentFile.UpdatePodTemplateAnnotations("kubernetes.io/change-cause", changeCause); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldSetter is a manifest object or file whose fields can be set by accessor path
+type fieldSetter interface {
+	SetField(accessorPath, value string) error
+}
+
+// setDeploymentFields sets the fields configured in settings.deployment_fields
+func setDeploymentFields(kuberniteConf *kuberniteConfig.Config, object fieldSetter, imageTag string) error {
+	for _, accessorPath := range sortedKeys(kuberniteConf.DeploymentFields) {
+		value, err := renderSettingTemplate(kuberniteConf.DeploymentFields[accessorPath], imageTag, kuberniteConf.BuildEvent)
+		if err != nil {
+			return err
+		}
+		if err := object.SetField(accessorPath, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+getImageUpdates returns the images to update. The containers selected by the deployment
+image name, container name, image pattern and container marker settings are updated to the
+deployed image tag and each of settings.deployment_images to its rendered tag.
+*/
+func getImageUpdates(kuberniteConf *kuberniteConfig.Config, imageTag string) ([]kubernetesManifest.ImageUpdate, error) {
+	var imageUpdates []kubernetesManifest.ImageUpdate
+	imageUpdate := kubernetesManifest.ImageUpdate{
+		ImageName:     kuberniteConf.DeploymentImageName,
+		ContainerName: kuberniteConf.DeploymentContainerName,
+		ImagePattern:  kuberniteConf.DeploymentImagePattern,
+		MarkerKey:     kuberniteConf.DeploymentContainerMarker,
+		Tag:           imageTag,
+	}
+	if imageUpdate.String() != "" || len(kuberniteConf.DeploymentImages) == 0 {
+		imageUpdates = append(imageUpdates, imageUpdate)
+	}
+	for _, imageName := range sortedKeys(kuberniteConf.DeploymentImages) {
+		tagTemplate := kuberniteConf.DeploymentImages[imageName]
+		if tagTemplate == "" {
+			tagTemplate = "{{.Tag}}"
+		}
+		imageUpdateTag, err := renderSettingTemplate(tagTemplate, imageTag, kuberniteConf.BuildEvent)
+		if err != nil {
+			return nil, err
+		}
+		imageUpdates = append(imageUpdates, kubernetesManifest.ImageUpdate{
+			ImageName: imageName,
+			Tag:       imageUpdateTag,
+		})
+	}
+	return imageUpdates, nil
+}
 
-	return deploymentFile, nil
+// sortedKeys returns the keys of a map setting so that it is applied in a stable order
+func sortedKeys(setting map[string]string) []string {
+	keys := make([]string, 0, len(setting))
+	for key := range setting {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// renderSettingTemplate renders a field value or image tag template setting, e.g. 'v{{.Tag}}'
+func renderSettingTemplate(settingTemplate, imageTag string, event git.Event) (string, error) {
+	parsedTemplate, err := template.New("setting").Option("missingkey=error").Parse(settingTemplate)
+	if err != nil {
+		return "", fmt.Errorf("error parsing template '%s': %s", settingTemplate, err)
+	}
+	var value bytes.Buffer
+	err = parsedTemplate.Execute(&value, struct {
+		Tag   string
+		Event string
+	}{
+		Tag:   imageTag,
+		Event: event.String(),
+	})
+	if err != nil {
+		return "", fmt.Errorf("error rendering template '%s': %s", settingTemplate, err)
+	}
+	return value.String(), nil
 }
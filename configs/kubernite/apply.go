@@ -0,0 +1,13 @@
+package kubernite
+
+// ApplyStrategy is how the updated object is applied to the cluster
+type ApplyStrategy string
+
+const (
+	// UpdateApplyStrategy replaces the whole object in the cluster with the updated object
+	UpdateApplyStrategy ApplyStrategy = "update"
+
+	// ServerSideApplyStrategy applies the updated object with server side apply so that
+	// fields managed by others are left alone
+	ServerSideApplyStrategy ApplyStrategy = "server_side"
+)
@@ -1,10 +1,13 @@
 package kubernite
 
 import (
+	"fmt"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
 	"gopkg.in/go-playground/validator.v9"
+	"kubernite/internal/pkg/tag"
 	"kubernite/pkg/git"
+	"time"
 )
 
 func init() {
@@ -13,16 +16,54 @@ func init() {
 	err = viper.BindEnv("KubernetesClientCertData", "PLUGIN_KUBERNETES_CLIENT_CERT_DATA")
 	err = viper.BindEnv("KubernetesClientKeyData", "PLUGIN_KUBERNETES_CLIENT_KEY_DATA")
 	err = viper.BindEnv("DeploymentFilePath", "PLUGIN_DEPLOYMENT_FILE_PATH")
+	err = viper.BindEnv("DeploymentKind", "PLUGIN_DEPLOYMENT_KIND")
+	err = viper.BindEnv("DeploymentName", "PLUGIN_DEPLOYMENT_NAME")
 	err = viper.BindEnv("DeploymentTagRepositoryPath", "PLUGIN_DEPLOYMENT_TAG_REPOSITORY_PATH")
 	err = viper.BindEnv("DeploymentImageName", "PLUGIN_DEPLOYMENT_IMAGE_NAME")
+	err = viper.BindEnv("DeploymentContainerName", "PLUGIN_DEPLOYMENT_CONTAINER_NAME")
+	err = viper.BindEnv("DeploymentImagePattern", "PLUGIN_DEPLOYMENT_IMAGE_PATTERN")
+	err = viper.BindEnv("DeploymentContainerMarker", "PLUGIN_DEPLOYMENT_CONTAINER_MARKER")
+	err = viper.BindEnv("DeploymentTagPrefix", "PLUGIN_DEPLOYMENT_TAG_PREFIX")
+	err = viper.BindEnv("DeploymentTagPattern", "PLUGIN_DEPLOYMENT_TAG_PATTERN")
+	err = viper.BindEnv("DeploymentCommitRevision", "PLUGIN_DEPLOYMENT_COMMIT_REVISION")
+	err = viper.BindEnv("DeploymentImageTagStrategy", "PLUGIN_DEPLOYMENT_IMAGE_TAG_STRATEGY")
+	err = viper.BindEnv("DeploymentImageTagTemplate", "PLUGIN_DEPLOYMENT_IMAGE_TAG_TEMPLATE")
+	err = viper.BindEnv("DeploymentImageTagSource", "PLUGIN_DEPLOYMENT_IMAGE_TAG_SOURCE")
+	err = viper.BindEnv("DeploymentImageTagsFilePath", "PLUGIN_DEPLOYMENT_IMAGE_TAGS_FILE_PATH")
+	err = viper.BindEnv("DeploymentImageTagsFileRule", "PLUGIN_DEPLOYMENT_IMAGE_TAGS_FILE_RULE")
+	err = viper.BindEnv("KustomizationPath", "PLUGIN_KUSTOMIZATION_PATH")
+	err = viper.BindEnv("KustomizationImageNewName", "PLUGIN_KUSTOMIZATION_IMAGE_NEW_NAME")
+	err = viper.BindEnv("KustomizeBinaryPath", "PLUGIN_KUSTOMIZE_BINARY_PATH")
+	err = viper.BindEnv("HelmValuesFilePath", "PLUGIN_HELM_VALUES_FILE_PATH")
+	err = viper.BindEnv("HelmValuesTagPath", "PLUGIN_HELM_VALUES_TAG_PATH")
+	err = viper.BindEnv("ApplyStrategy", "PLUGIN_APPLY_STRATEGY")
+	err = viper.BindEnv("ApplyForceConflicts", "PLUGIN_APPLY_FORCE_CONFLICTS")
+	err = viper.BindEnv("ApplyConflictRetries", "PLUGIN_APPLY_CONFLICT_RETRIES")
+	err = viper.BindEnv("RolloutWait", "PLUGIN_ROLLOUT_WAIT")
+	err = viper.BindEnv("RolloutTimeout", "PLUGIN_ROLLOUT_TIMEOUT")
+	err = viper.BindEnv("RolloutRollback", "PLUGIN_ROLLOUT_ROLLBACK")
+	err = viper.BindEnv("DiagnosticsLogLines", "PLUGIN_DIAGNOSTICS_LOG_LINES")
+	err = viper.BindEnv("DiagnosticsDirPath", "PLUGIN_DIAGNOSTICS_DIR_PATH")
 	err = viper.BindEnv("DryRun", "PLUGIN_DRY_RUN")
+	err = viper.BindEnv("DryRunLevel", "PLUGIN_DRY_RUN_LEVEL")
+	err = viper.BindEnv("DryRunLiveDiff", "PLUGIN_DRY_RUN_LIVE_DIFF")
+	err = viper.BindEnv("DryRunDiffFilePath", "PLUGIN_DRY_RUN_DIFF_FILE_PATH")
 	err = viper.BindEnv("DeploymentFileRepositoryPath", "PLUGIN_DEPLOYMENT_FILE_REPOSITORY_PATH")
 	err = viper.BindEnv("CommitDeployment", "PLUGIN_COMMIT_DEPLOYMENT")
 	err = viper.BindEnv("BuildEvent", "DRONE_BUILD_EVENT")
-	//TODO - used for git push
-	//err = viper.BindEnv("GitUsername", "PLUGIN_GIT_USERNAME")
-	//err = viper.BindEnv("GitPassword", "PLUGIN_GIT_PASSWORD")
-	//err = viper.BindEnv("GitKey", "PLUGIN_GIT_KEY")
+	err = viper.BindEnv("DroneTag", "DRONE_TAG")
+	err = viper.BindEnv("DroneBranch", "DRONE_BRANCH")
+	err = viper.BindEnv("DroneBuildNumber", "DRONE_BUILD_NUMBER")
+	err = viper.BindEnv("EventActions", "PLUGIN_EVENT_ACTIONS")
+	err = viper.BindEnv("EventDeploymentFilePaths", "PLUGIN_EVENT_DEPLOYMENT_FILE_PATHS")
+	err = viper.BindEnv("DeploymentFields", "PLUGIN_DEPLOYMENT_FIELDS")
+	err = viper.BindEnv("DeploymentImages", "PLUGIN_DEPLOYMENT_IMAGES")
+	err = viper.BindEnv("GitRemoteName", "PLUGIN_GIT_REMOTE_NAME")
+	err = viper.BindEnv("GitBranch", "PLUGIN_GIT_BRANCH")
+	err = viper.BindEnv("GitUsername", "PLUGIN_GIT_USERNAME")
+	err = viper.BindEnv("GitPassword", "PLUGIN_GIT_PASSWORD")
+	err = viper.BindEnv("GitKey", "PLUGIN_GIT_KEY")
+	err = viper.BindEnv("GitKnownHosts", "PLUGIN_GIT_KNOWN_HOSTS")
 	if err != nil {
 		err = ErrPackageInitialisation{Reasons: []string{
 			"binding viper keys to environment variables",
@@ -33,27 +74,78 @@ func init() {
 }
 
 type Config struct {
-	KubernetesServer             string `validate:"required"`
-	KubernetesCertData           string `validate:"required"`
-	KubernetesClientCertData     string `validate:"required"`
-	KubernetesClientKeyData      string `validate:"required"`
-	DeploymentFilePath           string `validate:"required"`
+	KubernetesServer             string `validate:"required_without=HelmValuesFilePath"`
+	KubernetesCertData           string `validate:"required_without=HelmValuesFilePath"`
+	KubernetesClientCertData     string `validate:"required_without=HelmValuesFilePath"`
+	KubernetesClientKeyData      string `validate:"required_without=HelmValuesFilePath"`
+	DeploymentFilePath           string `validate:"required_without_all=KustomizationPath HelmValuesFilePath"`
+	DeploymentKind               string
+	DeploymentName               string
 	DeploymentTagRepositoryPath  string
 	DeploymentImageName          string
+	DeploymentContainerName      string
+	DeploymentImagePattern       string
+	DeploymentContainerMarker    string
+	DeploymentTagPrefix          string
+	DeploymentTagPattern         string
+	DeploymentCommitRevision     string
+	DeploymentImageTagStrategy   tag.Strategy `validate:"oneof=latest commit_sha short_commit_sha branch build_number template"`
+	DeploymentImageTagTemplate   string
+	DeploymentImageTagSource     tag.Source `validate:"oneof=git file"`
+	DeploymentImageTagsFilePath  string
+	DeploymentImageTagsFileRule  tag.Rule `validate:"oneof=most_specific first last"`
+	KustomizationPath            string
+	KustomizationImageNewName    string
+	KustomizeBinaryPath          string
+	HelmValuesFilePath           string
+	HelmValuesTagPath            string
+	ApplyStrategy                ApplyStrategy `validate:"oneof=update server_side"`
+	ApplyForceConflicts          bool
+	ApplyConflictRetries         int `validate:"min=0"`
+	RolloutWait                  bool
+	RolloutTimeout               time.Duration `validate:"required_with=RolloutWait"`
+	RolloutRollback              bool
+	DiagnosticsLogLines          int64 `validate:"min=1"`
+	DiagnosticsDirPath           string
 	DryRun                       bool
-	DeploymentFileRepositoryPath string
+	DryRunLevel                  DryRunLevel `validate:"oneof=client server"`
+	DryRunLiveDiff               bool
+	DryRunDiffFilePath           string
+	DeploymentFileRepositoryPath string `validate:"required_with=CommitDeployment"`
 	CommitDeployment             bool
 	BuildEvent                   git.Event `validate:"required"`
-	//TODO - used for git push
-	//GitPassword                  string
-	//GitUsername                  string
-	//GitKey                       string
+	DroneTag                     string
+	DroneBranch                  string
+	DroneBuildNumber             string
+	EventActions                 map[git.Event]EventAction `mapstructure:"-"`
+	EventDeploymentFilePaths     map[git.Event]string      `mapstructure:"-"`
+	DeploymentFields             map[string]string         `mapstructure:"-"`
+	DeploymentImages             map[string]string         `mapstructure:"-"`
+	GitRemoteName                string
+	GitBranch                    string
+	GitUsername                  string
+	GitPassword                  string
+	GitKey                       string
+	GitKnownHosts                string
 }
 
 func GetConfig() (*Config, error) {
 	// set default configuration
 	viper.SetDefault("DeploymentTagRepositoryPath", "/drone/src")
+	viper.SetDefault("DeploymentImageTagStrategy", tag.LatestStrategy)
+	viper.SetDefault("DeploymentImageTagSource", tag.GitSource)
+	viper.SetDefault("DeploymentImageTagsFilePath", ".tags")
+	viper.SetDefault("DeploymentImageTagsFileRule", tag.MostSpecificRule)
+	viper.SetDefault("KustomizeBinaryPath", "kustomize")
+	viper.SetDefault("HelmValuesTagPath", "image.tag")
+	viper.SetDefault("ApplyStrategy", UpdateApplyStrategy)
+	viper.SetDefault("ApplyConflictRetries", 5)
+	viper.SetDefault("RolloutTimeout", "5m")
+	viper.SetDefault("DiagnosticsLogLines", 50)
+	viper.SetDefault("DiagnosticsDirPath", "kubernite-diagnostics")
 	viper.SetDefault("DryRun", false)
+	viper.SetDefault("DryRunLevel", ClientDryRun)
+	viper.SetDefault("GitRemoteName", "origin")
 
 	// parse the config from environment
 	conf := new(Config)
@@ -61,10 +153,33 @@ func GetConfig() (*Config, error) {
 		return nil, err
 	}
 
+	// parse the per event settings
+	if err := parseEventSettings(conf); err != nil {
+		return nil, err
+	}
+
+	// parse the fields to set by accessor path and the images to update
+	if err := unmarshalJSONSetting("DeploymentFields", &conf.DeploymentFields); err != nil {
+		return nil, err
+	}
+	if err := unmarshalJSONSetting("DeploymentImages", &conf.DeploymentImages); err != nil {
+		return nil, err
+	}
+
 	// validate the configuration
 	if err := validator.New().Struct(conf); err != nil {
 		return nil, ErrInvalidConfig{Reasons: []string{err.Error()}}
 	}
+	if !conf.BuildEvent.IsValid() {
+		return nil, ErrInvalidConfig{Reasons: []string{
+			fmt.Sprintf("unknown build event '%s'", conf.BuildEvent),
+		}}
+	}
+	if conf.HelmValuesFilePath != "" && conf.KustomizationPath != "" {
+		return nil, ErrInvalidConfig{Reasons: []string{
+			"only one of helm values file path and kustomization path can be set",
+		}}
+	}
 
 	return conf, nil
 }
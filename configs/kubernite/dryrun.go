@@ -0,0 +1,12 @@
+package kubernite
+
+// DryRunLevel is how far a dry run goes before stopping short of deploying
+type DryRunLevel string
+
+const (
+	// ClientDryRun only updates the deployment file locally
+	ClientDryRun DryRunLevel = "client"
+
+	// ServerDryRun also sends the update to the API server with dryRun=All
+	ServerDryRun DryRunLevel = "server"
+)
@@ -0,0 +1,83 @@
+package kubernite
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/spf13/viper"
+	"kubernite/pkg/git"
+)
+
+// EventAction is what kubernite does when it handles a build event
+type EventAction string
+
+const (
+	DeployEventAction EventAction = "deploy"
+	SkipEventAction   EventAction = "skip"
+)
+
+// defaultEventActions are the actions taken for events with no configured action.
+// Pull requests are skipped so that unreviewed changes are never deployed.
+var defaultEventActions = map[git.Event]EventAction{
+	git.PullRequestEvent: SkipEventAction,
+}
+
+// EventAction returns the action to take for the build event being handled
+func (c *Config) EventAction() EventAction {
+	if action, found := c.EventActions[c.BuildEvent]; found {
+		return action
+	}
+	if action, found := defaultEventActions[c.BuildEvent]; found {
+		return action
+	}
+	return DeployEventAction
+}
+
+// parseEventSettings parses the per event settings which drone passes to the
+// plugin as json objects keyed by event
+func parseEventSettings(conf *Config) error {
+	if err := unmarshalJSONSetting("EventActions", &conf.EventActions); err != nil {
+		return err
+	}
+	if err := unmarshalJSONSetting("EventDeploymentFilePaths", &conf.EventDeploymentFilePaths); err != nil {
+		return err
+	}
+
+	var reasons []string
+	for event, action := range conf.EventActions {
+		if !event.IsValid() {
+			reasons = append(reasons, fmt.Sprintf("event actions: unknown event '%s'", event))
+		}
+		if action != DeployEventAction && action != SkipEventAction {
+			reasons = append(reasons, fmt.Sprintf("event actions: unknown action '%s' for event '%s'", action, event))
+		}
+	}
+	for event := range conf.EventDeploymentFilePaths {
+		if !event.IsValid() {
+			reasons = append(reasons, fmt.Sprintf("event deployment file paths: unknown event '%s'", event))
+		}
+	}
+	if len(reasons) > 0 {
+		return ErrInvalidConfig{Reasons: reasons}
+	}
+
+	// deploy to the event specific deployment file if one is given
+	if deploymentFilePath, found := conf.EventDeploymentFilePaths[conf.BuildEvent]; found {
+		conf.DeploymentFilePath = deploymentFilePath
+	}
+
+	return nil
+}
+
+func unmarshalJSONSetting(key string, v interface{}) error {
+	setting := viper.GetString(key)
+	if setting == "" {
+		return nil
+	}
+	if err := json.Unmarshal([]byte(setting), v); err != nil {
+		return ErrInvalidConfig{Reasons: []string{
+			fmt.Sprintf("parsing %s", key),
+			err.Error(),
+		}}
+	}
+	return nil
+}
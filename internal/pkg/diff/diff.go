@@ -0,0 +1,75 @@
+package diff
+
+import (
+	"fmt"
+	"github.com/pmezard/go-difflib/difflib"
+	"strings"
+)
+
+// ansi colour escape codes used to colourise diffs for terminals
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiCyan  = "\x1b[36m"
+)
+
+/*
+Unified returns the unified diff with 3 lines of context between from and to, which are
+labelled with the given names. The diff is blank if there are no differences. Empty from
+or to, e.g. an object which does not exist yet, have no lines.
+*/
+func Unified(fromName, toName string, from, to []byte) (string, error) {
+	unifiedDiff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(from),
+		B:        splitLines(to),
+		FromFile: fromName,
+		ToFile:   toName,
+		Context:  3,
+	})
+	if err != nil {
+		return "", ErrDiffing{Reasons: []string{
+			fmt.Sprintf("diffing '%s' and '%s'", fromName, toName),
+			err.Error(),
+		}}
+	}
+	return unifiedDiff, nil
+}
+
+// splitLines splits text into lines for diffing, empty text has no lines
+func splitLines(text []byte) []string {
+	if len(text) == 0 {
+		return nil
+	}
+	// SplitLines ends the last line with a newline, so text ending in one would get an extra empty line
+	return difflib.SplitLines(strings.TrimSuffix(string(text), "\n"))
+}
+
+/*
+Colourise colours the lines of a unified diff for terminals: headers are bold, hunk
+ranges cyan, removed lines red and added lines green
+*/
+func Colourise(unifiedDiff string) string {
+	var colourised strings.Builder
+	for _, line := range strings.SplitAfter(unifiedDiff, "\n") {
+		if line == "" {
+			continue
+		}
+		text := strings.TrimSuffix(line, "\n")
+		switch {
+		case strings.HasPrefix(text, "---"), strings.HasPrefix(text, "+++"):
+			colourised.WriteString(ansiBold + text + ansiReset)
+		case strings.HasPrefix(text, "@@"):
+			colourised.WriteString(ansiCyan + text + ansiReset)
+		case strings.HasPrefix(text, "-"):
+			colourised.WriteString(ansiRed + text + ansiReset)
+		case strings.HasPrefix(text, "+"):
+			colourised.WriteString(ansiGreen + text + ansiReset)
+		default:
+			colourised.WriteString(text)
+		}
+		colourised.WriteString(line[len(text):])
+	}
+	return colourised.String()
+}
@@ -0,0 +1,11 @@
+package diff
+
+import "strings"
+
+type ErrDiffing struct {
+	Reasons []string
+}
+
+func (e ErrDiffing) Error() string {
+	return "error creating diff: " + strings.Join(e.Reasons, ", ")
+}
@@ -0,0 +1,53 @@
+package diff
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+// ignoredMetadataFields are set by the cluster and would otherwise appear in every live diff
+var ignoredMetadataFields = []string{
+	"creationTimestamp",
+	"generation",
+	"managedFields",
+	"resourceVersion",
+	"selfLink",
+	"uid",
+}
+
+// ignoredAnnotations are set by the cluster or kubectl rather than by the manifest
+var ignoredAnnotations = []string{
+	"deployment.kubernetes.io/revision",
+	"kubectl.kubernetes.io/last-applied-configuration",
+}
+
+/*
+ObjectYAML returns the given object content as yaml without its status and the metadata
+set by the cluster so that a live object can be compared with the desired object
+*/
+func ObjectYAML(object map[string]interface{}) ([]byte, error) {
+	content := runtime.DeepCopyJSON(object)
+	delete(content, "status")
+	if metadata, isMapping := content["metadata"].(map[string]interface{}); isMapping {
+		for _, field := range ignoredMetadataFields {
+			delete(metadata, field)
+		}
+		if annotations, isMapping := metadata["annotations"].(map[string]interface{}); isMapping {
+			for _, annotation := range ignoredAnnotations {
+				delete(annotations, annotation)
+			}
+			if len(annotations) == 0 {
+				delete(metadata, "annotations")
+			}
+		}
+	}
+
+	objectYAML, err := yaml.Marshal(content)
+	if err != nil {
+		return nil, ErrDiffing{Reasons: []string{
+			"encoding object",
+			err.Error(),
+		}}
+	}
+	return objectYAML, nil
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"fmt"
+	kubernetesErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	kubernetesManifest "kubernite/pkg/kubernetes/manifest"
+	"regexp"
+)
+
+// FieldManager is the field manager which owns the fields kubernite applies server side
+const FieldManager = "kubernite"
+
+// conflictManager matches the manager named in the message of a field manager conflict
+var conflictManager = regexp.MustCompile(`conflict with "([^"]*)"`)
+
+/*
+ServerSideApply applies the given object with server side apply as FieldManager so that
+only the fields in the manifest are owned by kubernite and fields managed by others, such
+as replicas set by a horizontal pod autoscaler, are left alone. The object is created if
+it does not exist. If force is set fields owned by other managers are taken over,
+otherwise conflicts are returned as ErrApplyConflict. If dryRun is set nothing is
+persisted. Jobs can not have their pod template updated and so are deleted first, unless
+this is a dry run. The object as stored by the server is returned.
+*/
+func (c *Client) ServerSideApply(object kubernetesManifest.Object, force, dryRun bool) (*unstructured.Unstructured, error) {
+	objectYAML, err := object.YAML()
+	if err != nil {
+		return nil, err
+	}
+	groupVersionKind := object.GetObjectKind().GroupVersionKind()
+	resourceClient, err := c.resourceClient(groupVersionKind, object.GetNamespace())
+	if err != nil {
+		return nil, ErrApplyingObject{Reasons: []string{
+			fmt.Sprintf("finding resource for %s", groupVersionKind),
+			err.Error(),
+		}}
+	}
+
+	if _, isJob := object.(*kubernetesManifest.Job); isJob && !dryRun {
+		propagationPolicy := metaV1.DeletePropagationBackground
+		if err := resourceClient.Delete(object.GetName(), &metaV1.DeleteOptions{
+			PropagationPolicy: &propagationPolicy,
+		}); err != nil && !kubernetesErrors.IsNotFound(err) {
+			return nil, ErrApplyingObject{Reasons: []string{
+				fmt.Sprintf("deleting Job '%s' to replace it", object.GetName()),
+				err.Error(),
+			}}
+		}
+	}
+
+	patchOptions := metaV1.PatchOptions{
+		FieldManager: FieldManager,
+		Force:        &force,
+	}
+	if dryRun {
+		patchOptions.DryRun = []string{metaV1.DryRunAll}
+	}
+	appliedObject, err := resourceClient.Patch(object.GetName(), types.ApplyPatchType, objectYAML, patchOptions)
+	if err != nil {
+		if conflictErr, isConflict := newErrApplyConflict(groupVersionKind.Kind, object.GetName(), err); isConflict {
+			return nil, conflictErr
+		}
+		return nil, ErrApplyingObject{Reasons: []string{
+			fmt.Sprintf("server side applying %s '%s'", groupVersionKind.Kind, object.GetName()),
+			err.Error(),
+		}}
+	}
+	return appliedObject, nil
+}
+
+/*
+newErrApplyConflict returns the field manager conflicts of a failed server side apply. It
+returns false if the error is not a conflict.
+*/
+func newErrApplyConflict(kind, name string, err error) (ErrApplyConflict, bool) {
+	statusErr, isStatusErr := err.(kubernetesErrors.APIStatus)
+	if !isStatusErr || !kubernetesErrors.IsConflict(err) || statusErr.Status().Details == nil {
+		return ErrApplyConflict{}, false
+	}
+
+	conflictErr := ErrApplyConflict{
+		Kind: kind,
+		Name: name,
+	}
+	for _, cause := range statusErr.Status().Details.Causes {
+		if cause.Type != metaV1.CauseTypeFieldManagerConflict {
+			continue
+		}
+		conflict := FieldConflict{
+			Field:   cause.Field,
+			Message: cause.Message,
+		}
+		if match := conflictManager.FindStringSubmatch(cause.Message); match != nil {
+			conflict.Manager = match[1]
+		}
+		conflictErr.Conflicts = append(conflictErr.Conflicts, conflict)
+	}
+	if len(conflictErr.Conflicts) == 0 {
+		return ErrApplyConflict{}, false
+	}
+	return conflictErr, true
+}
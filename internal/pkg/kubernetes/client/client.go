@@ -1,13 +1,24 @@
 package client
 
 import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	kubernetesRestClient "k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 	kuberniteConfig "kubernite/configs/kubernite"
 )
 
 type Client struct {
 	*kubernetes.Clientset
+
+	// Dynamic and RESTMapper are used to apply objects of kinds without a typed client
+	Dynamic    dynamic.Interface
+	RESTMapper meta.RESTMapper
+
+	// ConflictRetries is how often an update which conflicts with a change in the cluster is retried
+	ConflictRetries int
 }
 
 func NewClientFromKuberniteConfig(kuberniteConf *kuberniteConfig.Config) (*Client, error) {
@@ -26,7 +37,19 @@ func NewClientFromKuberniteConfig(kuberniteConf *kuberniteConfig.Config) (*Clien
 		}}
 	}
 
+	// create the dynamic client
+	dynamicClient, err := dynamic.NewForConfig(restClientConfig)
+	if err != nil {
+		return nil, ErrCreatingClientSet{Reasons: []string{
+			"creating dynamic client",
+			err.Error(),
+		}}
+	}
+
 	return &Client{
-		Clientset: clientset,
+		Clientset:       clientset,
+		Dynamic:         dynamicClient,
+		RESTMapper:      restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(clientset.Discovery())),
+		ConflictRetries: kuberniteConf.ApplyConflictRetries,
 	}, nil
 }
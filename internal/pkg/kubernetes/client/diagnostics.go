@@ -0,0 +1,213 @@
+package client
+
+import (
+	"fmt"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	kubernetesManifest "kubernite/pkg/kubernetes/manifest"
+	"sort"
+	"strings"
+)
+
+/*
+Diagnostics are the events of an object, collected to explain why applying it failed. For
+a deployment whose rollout failed they include the events of its replica sets and the
+events and logs of its pods.
+*/
+type Diagnostics struct {
+	Kind   string
+	Name   string
+	Events []string
+	Pods   []PodDiagnostics
+}
+
+/*
+PodDiagnostics are the events of a pod and the last log lines of its failing containers
+by container name
+*/
+type PodDiagnostics struct {
+	Name   string
+	Events []string
+	Logs   map[string]string
+}
+
+/*
+CollectDiagnostics collects the events of the given deployment, its replica sets and its
+pods and the last log lines of the containers of its pods which are not ready. Logs of
+containers which restarted are taken from their previous run, i.e. the one which crashed.
+*/
+func (c *Client) CollectDiagnostics(workload kubernetesManifest.Workload, logLines int64) (*Diagnostics, error) {
+	namespace := workload.GetNamespace()
+	if namespace == "" {
+		namespace = metaV1.NamespaceDefault
+	}
+	deployment, err := c.AppsV1().Deployments(namespace).Get(workload.GetName(), metaV1.GetOptions{})
+	if err != nil {
+		return nil, ErrCollectingDiagnostics{Reasons: []string{
+			fmt.Sprintf("getting deployment '%s'", workload.GetName()),
+			err.Error(),
+		}}
+	}
+	replicaSets, err := c.deploymentReplicaSets(deployment)
+	if err != nil {
+		return nil, ErrCollectingDiagnostics{Reasons: []string{
+			fmt.Sprintf("getting replica sets of deployment '%s'", deployment.Name),
+			err.Error(),
+		}}
+	}
+
+	diagnostics := &Diagnostics{Kind: "Deployment", Name: deployment.Name}
+	events, err := c.objectEvents(namespace, "Deployment", deployment.Name)
+	if err != nil {
+		return nil, err
+	}
+	diagnostics.Events = append(diagnostics.Events, events...)
+
+	for i := range replicaSets {
+		events, err := c.objectEvents(namespace, "ReplicaSet", replicaSets[i].Name)
+		if err != nil {
+			return nil, err
+		}
+		diagnostics.Events = append(diagnostics.Events, events...)
+
+		pods, err := c.replicaSetPods(&replicaSets[i])
+		if err != nil {
+			return nil, ErrCollectingDiagnostics{Reasons: []string{
+				fmt.Sprintf("getting pods of replica set '%s'", replicaSets[i].Name),
+				err.Error(),
+			}}
+		}
+		for j := range pods {
+			podDiagnostics, err := c.podDiagnostics(&pods[j], logLines)
+			if err != nil {
+				return nil, err
+			}
+			diagnostics.Pods = append(diagnostics.Pods, podDiagnostics)
+		}
+	}
+	sort.Slice(diagnostics.Pods, func(i, j int) bool {
+		return diagnostics.Pods[i].Name < diagnostics.Pods[j].Name
+	})
+	return diagnostics, nil
+}
+
+/*
+CollectObjectDiagnostics collects the events of the given object, e.g. after applying it
+to the cluster failed
+*/
+func (c *Client) CollectObjectDiagnostics(object kubernetesManifest.Object) (*Diagnostics, error) {
+	namespace := object.GetNamespace()
+	if namespace == "" {
+		namespace = metaV1.NamespaceDefault
+	}
+	kind := object.GetObjectKind().GroupVersionKind().Kind
+	events, err := c.objectEvents(namespace, kind, object.GetName())
+	if err != nil {
+		return nil, err
+	}
+	return &Diagnostics{Kind: kind, Name: object.GetName(), Events: events}, nil
+}
+
+// podDiagnostics collects the events of the pod and the last log lines of its containers which are not ready
+func (c *Client) podDiagnostics(pod *coreV1.Pod, logLines int64) (PodDiagnostics, error) {
+	events, err := c.objectEvents(pod.Namespace, "Pod", pod.Name)
+	if err != nil {
+		return PodDiagnostics{}, err
+	}
+	podDiagnostics := PodDiagnostics{
+		Name:   pod.Name,
+		Events: events,
+		Logs:   make(map[string]string),
+	}
+
+	for _, containerStatus := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		if containerStatus.Ready {
+			continue
+		}
+		tailLines := logLines
+		logs, err := c.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &coreV1.PodLogOptions{
+			Container: containerStatus.Name,
+			TailLines: &tailLines,
+			Previous:  containerStatus.RestartCount > 0,
+		}).Do().Raw()
+		if err != nil {
+			// a container which has not started yet has no logs, which is not an error collecting diagnostics
+			podDiagnostics.Logs[containerStatus.Name] = fmt.Sprintf("error getting logs: %s", err)
+			continue
+		}
+		podDiagnostics.Logs[containerStatus.Name] = string(logs)
+	}
+	return podDiagnostics, nil
+}
+
+// objectEvents returns the events of the object with the given kind and name, oldest first
+func (c *Client) objectEvents(namespace, kind, name string) ([]string, error) {
+	eventList, err := c.CoreV1().Events(namespace).List(metaV1.ListOptions{
+		FieldSelector: fields.Set{
+			"involvedObject.kind": kind,
+			"involvedObject.name": name,
+		}.AsSelector().String(),
+	})
+	if err != nil {
+		return nil, ErrCollectingDiagnostics{Reasons: []string{
+			fmt.Sprintf("getting events of %s '%s'", kind, name),
+			err.Error(),
+		}}
+	}
+	sort.Slice(eventList.Items, func(i, j int) bool {
+		return eventList.Items[i].LastTimestamp.Before(&eventList.Items[j].LastTimestamp)
+	})
+
+	var events []string
+	for _, event := range eventList.Items {
+		events = append(events, fmt.Sprintf("%s %s %s %s/%s: %s (x%d)",
+			event.LastTimestamp.UTC().Format("2006-01-02T15:04:05Z"),
+			event.Type,
+			event.Reason,
+			kind,
+			name,
+			strings.TrimSpace(event.Message),
+			event.Count,
+		))
+	}
+	return events, nil
+}
+
+func (d *Diagnostics) String() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "==== %s %s ====\n", strings.ToLower(d.Kind), d.Name)
+	writeEvents(&builder, d.Events)
+	return builder.String()
+}
+
+func (p PodDiagnostics) String() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "==== pod %s ====\n", p.Name)
+	writeEvents(&builder, p.Events)
+
+	containerNames := make([]string, 0, len(p.Logs))
+	for containerName := range p.Logs {
+		containerNames = append(containerNames, containerName)
+	}
+	sort.Strings(containerNames)
+	for _, containerName := range containerNames {
+		fmt.Fprintf(&builder, "---- logs of container %s ----\n", containerName)
+		builder.WriteString(p.Logs[containerName])
+		if logs := p.Logs[containerName]; logs != "" && !strings.HasSuffix(logs, "\n") {
+			builder.WriteString("\n")
+		}
+	}
+	return builder.String()
+}
+
+// writeEvents writes the events section of the diagnostics of an object
+func writeEvents(builder *strings.Builder, events []string) {
+	builder.WriteString("---- events ----\n")
+	if len(events) == 0 {
+		builder.WriteString("no events\n")
+	}
+	for _, event := range events {
+		builder.WriteString(event + "\n")
+	}
+}
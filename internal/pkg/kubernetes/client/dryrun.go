@@ -0,0 +1,95 @@
+package client
+
+import (
+	"fmt"
+	kubernetesErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	kubernetesManifest "kubernite/pkg/kubernetes/manifest"
+	"sigs.k8s.io/yaml"
+)
+
+/*
+DryRunObject sends the update of the given object, or its create if it does not exist, to
+the API server with dryRun=All so that authentication, authorisation, admission and
+validation run without anything being persisted. It returns the object as it would be
+stored, with defaults set by the server.
+Jobs are replaced rather than updated and so are dry run as a create with a generated
+name, which validates the job but not that it can replace the existing job.
+*/
+func (c *Client) DryRunObject(object kubernetesManifest.Object) (*unstructured.Unstructured, error) {
+	desiredObject, err := toUnstructured(object)
+	if err != nil {
+		return nil, err
+	}
+	groupVersionKind := desiredObject.GroupVersionKind()
+	resourceClient, err := c.resourceClient(groupVersionKind, desiredObject.GetNamespace())
+	if err != nil {
+		return nil, ErrServerDryRun{Reasons: []string{
+			fmt.Sprintf("finding resource for %s", groupVersionKind),
+			err.Error(),
+		}}
+	}
+
+	var dryRunObject *unstructured.Unstructured
+	if _, isJob := object.(*kubernetesManifest.Job); isJob {
+		desiredObject.SetGenerateName(desiredObject.GetName() + "-")
+		desiredObject.SetName("")
+		dryRunObject, err = resourceClient.Create(desiredObject, metaV1.CreateOptions{
+			DryRun: []string{metaV1.DryRunAll},
+		})
+	} else {
+		// as when applying, the update is made against the resource version of the live object
+		// since custom resources can not be updated unconditionally
+		var liveObject *unstructured.Unstructured
+		liveObject, err = resourceClient.Get(desiredObject.GetName(), metaV1.GetOptions{})
+		switch {
+		case kubernetesErrors.IsNotFound(err):
+			dryRunObject, err = resourceClient.Create(desiredObject, metaV1.CreateOptions{
+				DryRun: []string{metaV1.DryRunAll},
+			})
+		case err == nil:
+			desiredObject.SetResourceVersion(liveObject.GetResourceVersion())
+			dryRunObject, err = resourceClient.Update(desiredObject, metaV1.UpdateOptions{
+				DryRun: []string{metaV1.DryRunAll},
+			})
+		}
+	}
+	if err != nil {
+		return nil, ErrServerDryRun{Reasons: []string{
+			fmt.Sprintf("%s '%s' rejected by the API server", groupVersionKind.Kind, object.GetName()),
+			err.Error(),
+		}}
+	}
+	return dryRunObject, nil
+}
+
+// toUnstructured converts the yaml of the given manifest object to an unstructured object
+func toUnstructured(object kubernetesManifest.Object) (*unstructured.Unstructured, error) {
+	objectYAML, err := object.YAML()
+	if err != nil {
+		return nil, err
+	}
+	objectJSON, err := yaml.YAMLToJSON(objectYAML)
+	if err != nil {
+		return nil, ErrServerDryRun{Reasons: []string{
+			"converting object yaml to json",
+			err.Error(),
+		}}
+	}
+	decodedObject, err := runtime.Decode(unstructured.UnstructuredJSONScheme, objectJSON)
+	if err != nil {
+		return nil, ErrServerDryRun{Reasons: []string{
+			"decoding unstructured object",
+			err.Error(),
+		}}
+	}
+	unstructuredObject, isUnstructured := decodedObject.(*unstructured.Unstructured)
+	if !isUnstructured {
+		return nil, ErrServerDryRun{Reasons: []string{
+			fmt.Sprintf("unexpected object type %T", decodedObject),
+		}}
+	}
+	return unstructuredObject, nil
+}
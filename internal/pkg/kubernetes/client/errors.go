@@ -1,6 +1,9 @@
 package client
 
-import "strings"
+import (
+	"fmt"
+	"strings"
+)
 
 type ErrCreatingClientSet struct {
 	Reasons []string
@@ -9,3 +12,91 @@ type ErrCreatingClientSet struct {
 func (e ErrCreatingClientSet) Error() string {
 	return "error creating client set: " + strings.Join(e.Reasons, ", ")
 }
+
+type ErrApplyingObject struct {
+	Reasons []string
+}
+
+func (e ErrApplyingObject) Error() string {
+	return "error applying object: " + strings.Join(e.Reasons, ", ")
+}
+
+type ErrGettingLiveObject struct {
+	Reasons []string
+}
+
+func (e ErrGettingLiveObject) Error() string {
+	return "error getting live object: " + strings.Join(e.Reasons, ", ")
+}
+
+type ErrServerDryRun struct {
+	Reasons []string
+}
+
+func (e ErrServerDryRun) Error() string {
+	return "server dry run failed: " + strings.Join(e.Reasons, ", ")
+}
+
+/*
+FieldConflict is a field which kubernite tried to apply that is owned by another field manager
+*/
+type FieldConflict struct {
+	Manager string
+	Field   string
+	Message string
+}
+
+type ErrApplyConflict struct {
+	Kind      string
+	Name      string
+	Conflicts []FieldConflict
+}
+
+func (e ErrApplyConflict) Error() string {
+	conflicts := make([]string, len(e.Conflicts))
+	for i, conflict := range e.Conflicts {
+		conflicts[i] = fmt.Sprintf("%s is managed by '%s'", conflict.Field, conflict.Manager)
+	}
+	return fmt.Sprintf("conflicts server side applying %s '%s': ", e.Kind, e.Name) +
+		strings.Join(conflicts, ", ") +
+		", force conflicts to take ownership of the fields"
+}
+
+/*
+Managers returns the field managers which own conflicting fields
+*/
+func (e ErrApplyConflict) Managers() []string {
+	var managers []string
+	seen := make(map[string]bool)
+	for _, conflict := range e.Conflicts {
+		if !seen[conflict.Manager] {
+			seen[conflict.Manager] = true
+			managers = append(managers, conflict.Manager)
+		}
+	}
+	return managers
+}
+
+type ErrRolloutFailed struct {
+	Reasons []string
+}
+
+func (e ErrRolloutFailed) Error() string {
+	return "error rolling out: " + strings.Join(e.Reasons, ", ")
+}
+
+type ErrRollingBack struct {
+	Reasons []string
+}
+
+func (e ErrRollingBack) Error() string {
+	return "error rolling back: " + strings.Join(e.Reasons, ", ")
+}
+
+type ErrCollectingDiagnostics struct {
+	Reasons []string
+}
+
+func (e ErrCollectingDiagnostics) Error() string {
+	return "error collecting diagnostics: " + strings.Join(e.Reasons, ", ")
+}
@@ -0,0 +1,59 @@
+package client
+
+import (
+	"fmt"
+	kubernetesErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	kubernetesManifest "kubernite/pkg/kubernetes/manifest"
+)
+
+/*
+GetLiveObject gets the object with the kind, namespace and name of the given object from
+the cluster
+*/
+func (c *Client) GetLiveObject(object kubernetesManifest.Object) (*unstructured.Unstructured, error) {
+	groupVersionKind := object.GetObjectKind().GroupVersionKind()
+	resourceClient, err := c.resourceClient(groupVersionKind, object.GetNamespace())
+	if err != nil {
+		return nil, ErrGettingLiveObject{Reasons: []string{
+			fmt.Sprintf("finding resource for %s", groupVersionKind),
+			err.Error(),
+		}}
+	}
+	liveObject, err := resourceClient.Get(object.GetName(), metaV1.GetOptions{})
+	if err != nil {
+		return nil, ErrGettingLiveObject{Reasons: []string{
+			fmt.Sprintf("getting %s '%s'", groupVersionKind.Kind, object.GetName()),
+			err.Error(),
+		}}
+	}
+	return liveObject, nil
+}
+
+/*
+ObjectExists returns true if the object with the kind, namespace and name of the given
+object exists in the cluster
+*/
+func (c *Client) ObjectExists(object kubernetesManifest.Object) (bool, error) {
+	groupVersionKind := object.GetObjectKind().GroupVersionKind()
+	resourceClient, err := c.resourceClient(groupVersionKind, object.GetNamespace())
+	if err != nil {
+		return false, ErrGettingLiveObject{Reasons: []string{
+			fmt.Sprintf("finding resource for %s", groupVersionKind),
+			err.Error(),
+		}}
+	}
+	_, err = resourceClient.Get(object.GetName(), metaV1.GetOptions{})
+	switch {
+	case err == nil:
+		return true, nil
+	case kubernetesErrors.IsNotFound(err):
+		return false, nil
+	default:
+		return false, ErrGettingLiveObject{Reasons: []string{
+			fmt.Sprintf("getting %s '%s'", groupVersionKind.Kind, object.GetName()),
+			err.Error(),
+		}}
+	}
+}
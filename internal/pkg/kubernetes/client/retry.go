@@ -0,0 +1,56 @@
+package client
+
+import (
+	log "github.com/sirupsen/logrus"
+	kubernetesErrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kubernetesManifest "kubernite/pkg/kubernetes/manifest"
+	"time"
+)
+
+// conflictBackoff is the delay between retries of an update which conflicts with a change in the cluster
+var conflictBackoff = wait.Backoff{
+	Duration: 500 * time.Millisecond,
+	Factor:   2,
+	Jitter:   0.1,
+	Cap:      10 * time.Second,
+}
+
+/*
+retryOnConflict retries an update of the given object which failed with the given conflict
+error, e.g. because the object was scaled while the pipeline runs. Each retry re-reads the
+live object, makes only the changes kubernite made to the object, e.g. the image and
+annotations, to it and updates it with the resource version of the live object.
+*/
+func (c *Client) retryOnConflict(object kubernetesManifest.Object, err error) error {
+	groupVersionKind := object.GetObjectKind().GroupVersionKind()
+	resourceClient, resourceErr := c.resourceClient(groupVersionKind, object.GetNamespace())
+	if resourceErr != nil {
+		return resourceErr
+	}
+
+	backoff := conflictBackoff
+	backoff.Steps = c.ConflictRetries
+	for attempt := 1; attempt <= c.ConflictRetries && kubernetesErrors.IsConflict(err); attempt++ {
+		delay := backoff.Step()
+		log.WithFields(log.Fields{
+			"kind":    groupVersionKind.Kind,
+			"name":    object.GetName(),
+			"attempt": attempt,
+			"retries": c.ConflictRetries,
+			"delay":   delay,
+		}).Warnf("conflict applying object, retrying with live object: %s", err)
+		time.Sleep(delay)
+
+		liveObject, getErr := resourceClient.Get(object.GetName(), metaV1.GetOptions{})
+		if getErr != nil {
+			return getErr
+		}
+		if editErr := object.ApplyEdits(liveObject.UnstructuredContent()); editErr != nil {
+			return editErr
+		}
+		_, err = resourceClient.Update(liveObject, metaV1.UpdateOptions{})
+	}
+	return err
+}
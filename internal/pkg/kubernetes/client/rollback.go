@@ -0,0 +1,88 @@
+package client
+
+import (
+	"fmt"
+	appsV1 "k8s.io/api/apps/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	kubernetesManifest "kubernite/pkg/kubernetes/manifest"
+	"strconv"
+)
+
+// changeCauseAnnotation is the annotation recording why a revision of a deployment was rolled out
+const changeCauseAnnotation = "kubernetes.io/change-cause"
+
+/*
+RollbackDeployment rolls the given deployment back to its previous revision the way
+kubectl rollout undo does, by restoring the pod template of the replica set of the
+previous revision. It returns the revision rolled back to.
+*/
+func (c *Client) RollbackDeployment(workload kubernetesManifest.Workload) (int64, error) {
+	namespace := workload.GetNamespace()
+	if namespace == "" {
+		namespace = metaV1.NamespaceDefault
+	}
+	deploymentClient := c.AppsV1().Deployments(namespace)
+
+	var previousRevision int64
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		deployment, err := deploymentClient.Get(workload.GetName(), metaV1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		replicaSets, err := c.deploymentReplicaSets(deployment)
+		if err != nil {
+			return err
+		}
+		previousReplicaSet, revision := previousRevisionReplicaSet(deployment, replicaSets)
+		if previousReplicaSet == nil {
+			return fmt.Errorf("no previous revision of deployment '%s'", deployment.Name)
+		}
+		previousRevision = revision
+
+		// restore the pod template without the label added to it by the deployment controller
+		template := previousReplicaSet.Spec.Template.DeepCopy()
+		delete(template.Labels, appsV1.DefaultDeploymentUniqueLabelKey)
+		deployment.Spec.Template = *template
+		if changeCause, found := previousReplicaSet.Annotations[changeCauseAnnotation]; found {
+			if deployment.Annotations == nil {
+				deployment.Annotations = make(map[string]string)
+			}
+			deployment.Annotations[changeCauseAnnotation] = changeCause
+		}
+
+		_, err = deploymentClient.Update(deployment)
+		return err
+	})
+	if err != nil {
+		return 0, ErrRollingBack{Reasons: []string{
+			fmt.Sprintf("rolling back deployment '%s'", workload.GetName()),
+			err.Error(),
+		}}
+	}
+	return previousRevision, nil
+}
+
+// previousRevisionReplicaSet returns the replica set of the revision before the current revision of the deployment
+func previousRevisionReplicaSet(deployment *appsV1.Deployment, replicaSets []appsV1.ReplicaSet) (*appsV1.ReplicaSet, int64) {
+	currentRevision := revision(deployment.Annotations)
+	var previousReplicaSet *appsV1.ReplicaSet
+	var previousRevision int64
+	for i := range replicaSets {
+		replicaSetRevision := revision(replicaSets[i].Annotations)
+		if replicaSetRevision < currentRevision && replicaSetRevision > previousRevision {
+			previousReplicaSet = &replicaSets[i]
+			previousRevision = replicaSetRevision
+		}
+	}
+	return previousReplicaSet, previousRevision
+}
+
+// revision returns the revision recorded in the given annotations or zero if there is none
+func revision(annotations map[string]string) int64 {
+	revision, err := strconv.ParseInt(annotations[revisionAnnotation], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return revision
+}
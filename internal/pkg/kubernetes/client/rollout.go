@@ -0,0 +1,189 @@
+package client
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	appsV1 "k8s.io/api/apps/v1"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kubernetesManifest "kubernite/pkg/kubernetes/manifest"
+	"strings"
+	"time"
+)
+
+// rolloutPollInterval is how often the status of a rollout is checked
+const rolloutPollInterval = 2 * time.Second
+
+// progressDeadlineExceededReason is the reason of the Progressing condition of a deployment whose progress deadline passed
+const progressDeadlineExceededReason = "ProgressDeadlineExceeded"
+
+// crashLoopBackOffReason is the waiting reason of a container which repeatedly crashes
+const crashLoopBackOffReason = "CrashLoopBackOff"
+
+// revisionAnnotation is the annotation of the revision of a deployment and its replica sets
+const revisionAnnotation = "deployment.kubernetes.io/revision"
+
+/*
+WaitForRollout waits until the rollout of the given deployment is complete, i.e. the
+deployment controller observed the updated deployment and all replicas are updated and
+available. The rollout fails if the progress deadline of the deployment passes, pods of
+the new replica set are crash looping or it is not complete within the given timeout.
+Rollouts of other kinds are not waited for.
+*/
+func (c *Client) WaitForRollout(workload kubernetesManifest.Workload, timeout time.Duration) error {
+	if _, isDeployment := workload.(*kubernetesManifest.Deployment); !isDeployment {
+		log.Info(fmt.Sprintf("not waiting for rollout of %s '%s'", workload.GetObjectKind().GroupVersionKind().Kind, workload.GetName()))
+		return nil
+	}
+	namespace := workload.GetNamespace()
+	if namespace == "" {
+		namespace = metaV1.NamespaceDefault
+	}
+
+	var lastStatus string
+	err := wait.PollImmediate(rolloutPollInterval, timeout, func() (bool, error) {
+		deployment, err := c.AppsV1().Deployments(namespace).Get(workload.GetName(), metaV1.GetOptions{})
+		if err != nil {
+			return false, ErrRolloutFailed{Reasons: []string{
+				fmt.Sprintf("getting deployment '%s'", workload.GetName()),
+				err.Error(),
+			}}
+		}
+		status, done, err := deploymentRolloutStatus(deployment)
+		if status != lastStatus {
+			log.Info(status)
+			lastStatus = status
+		}
+		if done || err != nil {
+			return done, err
+		}
+
+		crashLoopingPods, err := c.crashLoopingPods(deployment)
+		if err != nil {
+			return false, ErrRolloutFailed{Reasons: []string{
+				fmt.Sprintf("getting pods of deployment '%s'", deployment.Name),
+				err.Error(),
+			}}
+		}
+		if len(crashLoopingPods) > 0 {
+			return false, ErrRolloutFailed{Reasons: []string{
+				fmt.Sprintf("deployment '%s' has crash looping pods: [%s]", deployment.Name, strings.Join(crashLoopingPods, ", ")),
+			}}
+		}
+		return false, nil
+	})
+	if err == wait.ErrWaitTimeout {
+		return ErrRolloutFailed{Reasons: []string{
+			fmt.Sprintf("deployment '%s' rollout not complete within %s", workload.GetName(), timeout),
+			lastStatus,
+		}}
+	}
+	return err
+}
+
+// deploymentRolloutStatus returns a message describing the rollout status of the deployment and if the rollout is complete
+func deploymentRolloutStatus(deployment *appsV1.Deployment) (string, bool, error) {
+	if deployment.Generation > deployment.Status.ObservedGeneration {
+		return fmt.Sprintf("waiting for deployment '%s' to be observed", deployment.Name), false, nil
+	}
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsV1.DeploymentProgressing && condition.Status == coreV1.ConditionFalse &&
+			condition.Reason == progressDeadlineExceededReason {
+			return condition.Message, false, ErrRolloutFailed{Reasons: []string{
+				fmt.Sprintf("deployment '%s' exceeded its progress deadline", deployment.Name),
+				condition.Message,
+			}}
+		}
+	}
+
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	status := deployment.Status
+	switch {
+	case status.UpdatedReplicas < replicas:
+		return fmt.Sprintf("waiting for deployment '%s' rollout: %d of %d replicas updated", deployment.Name, status.UpdatedReplicas, replicas), false, nil
+	case status.Replicas > status.UpdatedReplicas:
+		return fmt.Sprintf("waiting for deployment '%s' rollout: %d old replicas pending termination", deployment.Name, status.Replicas-status.UpdatedReplicas), false, nil
+	case status.AvailableReplicas < status.UpdatedReplicas:
+		return fmt.Sprintf("waiting for deployment '%s' rollout: %d of %d updated replicas available", deployment.Name, status.AvailableReplicas, status.UpdatedReplicas), false, nil
+	}
+	return fmt.Sprintf("deployment '%s' successfully rolled out", deployment.Name), true, nil
+}
+
+// crashLoopingPods returns the names of the pods of the new replica set of the deployment which have a crash looping container
+func (c *Client) crashLoopingPods(deployment *appsV1.Deployment) ([]string, error) {
+	replicaSets, err := c.deploymentReplicaSets(deployment)
+	if err != nil {
+		return nil, err
+	}
+	var newReplicaSet *appsV1.ReplicaSet
+	for i := range replicaSets {
+		if replicaSets[i].Annotations[revisionAnnotation] == deployment.Annotations[revisionAnnotation] {
+			newReplicaSet = &replicaSets[i]
+			break
+		}
+	}
+	if newReplicaSet == nil {
+		return nil, nil
+	}
+
+	pods, err := c.replicaSetPods(newReplicaSet)
+	if err != nil {
+		return nil, err
+	}
+	var crashLoopingPods []string
+	for _, pod := range pods {
+		for _, containerStatus := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+			if containerStatus.State.Waiting != nil && containerStatus.State.Waiting.Reason == crashLoopBackOffReason {
+				crashLoopingPods = append(crashLoopingPods, pod.Name)
+				break
+			}
+		}
+	}
+	return crashLoopingPods, nil
+}
+
+// deploymentReplicaSets returns the replica sets controlled by the deployment
+func (c *Client) deploymentReplicaSets(deployment *appsV1.Deployment) ([]appsV1.ReplicaSet, error) {
+	selector, err := metaV1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	replicaSetList, err := c.AppsV1().ReplicaSets(deployment.Namespace).List(metaV1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var replicaSets []appsV1.ReplicaSet
+	for _, replicaSet := range replicaSetList.Items {
+		if metaV1.IsControlledBy(&replicaSet, deployment) {
+			replicaSets = append(replicaSets, replicaSet)
+		}
+	}
+	return replicaSets, nil
+}
+
+// replicaSetPods returns the pods controlled by the replica set
+func (c *Client) replicaSetPods(replicaSet *appsV1.ReplicaSet) ([]coreV1.Pod, error) {
+	selector, err := metaV1.LabelSelectorAsSelector(replicaSet.Spec.Selector)
+	if err != nil {
+		return nil, err
+	}
+	podList, err := c.CoreV1().Pods(replicaSet.Namespace).List(metaV1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var pods []coreV1.Pod
+	for _, pod := range podList.Items {
+		if metaV1.IsControlledBy(&pod, replicaSet) {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
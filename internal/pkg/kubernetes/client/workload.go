@@ -0,0 +1,141 @@
+package client
+
+import (
+	"fmt"
+	kubernetesErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	kubernetesManifest "kubernite/pkg/kubernetes/manifest"
+)
+
+/*
+ApplyObject creates the given object in the cluster if it does not exist and otherwise
+updates it. Workloads are applied with their typed client and all other objects with the
+dynamic client.
+*/
+func (c *Client) ApplyObject(object kubernetesManifest.Object) error {
+	switch o := object.(type) {
+	case kubernetesManifest.Workload:
+		return c.ApplyWorkload(o)
+	case *kubernetesManifest.Unstructured:
+		return c.applyUnstructured(o)
+	default:
+		return ErrApplyingObject{Reasons: []string{
+			fmt.Sprintf("unsupported object type %T", object),
+		}}
+	}
+}
+
+func (c *Client) applyUnstructured(object *kubernetesManifest.Unstructured) error {
+	groupVersionKind := object.GroupVersionKind()
+	resourceClient, err := c.resourceClient(groupVersionKind, object.GetNamespace())
+	if err != nil {
+		return ErrApplyingObject{Reasons: []string{
+			fmt.Sprintf("finding resource for %s", groupVersionKind),
+			err.Error(),
+		}}
+	}
+	// custom resources can not be updated unconditionally, so the update is made against the resource version of the live object
+	liveObject, err := resourceClient.Get(object.GetName(), metaV1.GetOptions{})
+	switch {
+	case kubernetesErrors.IsNotFound(err):
+		_, err = resourceClient.Create(object.Unstructured, metaV1.CreateOptions{})
+	case err == nil:
+		object.SetResourceVersion(liveObject.GetResourceVersion())
+		_, err = resourceClient.Update(object.Unstructured, metaV1.UpdateOptions{})
+	}
+	if kubernetesErrors.IsConflict(err) {
+		err = c.retryOnConflict(object, err)
+	}
+	if err != nil {
+		return ErrApplyingObject{Reasons: []string{
+			fmt.Sprintf("applying %s '%s'", groupVersionKind.Kind, object.GetName()),
+			err.Error(),
+		}}
+	}
+	return nil
+}
+
+// resourceClient returns the dynamic client for the resource of the given kind in the given namespace
+func (c *Client) resourceClient(groupVersionKind schema.GroupVersionKind, namespace string) (dynamic.ResourceInterface, error) {
+	mapping, err := c.RESTMapper.RESTMapping(groupVersionKind.GroupKind(), groupVersionKind.Version)
+	if err != nil {
+		return nil, err
+	}
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		if namespace == "" {
+			namespace = metaV1.NamespaceDefault
+		}
+		return c.Dynamic.Resource(mapping.Resource).Namespace(namespace), nil
+	}
+	return c.Dynamic.Resource(mapping.Resource), nil
+}
+
+/*
+ApplyWorkload creates the given workload in the cluster if it does not exist and otherwise
+updates it, using the typed client of its kind. Jobs can not have their pod template
+updated and so are replaced.
+*/
+func (c *Client) ApplyWorkload(workload kubernetesManifest.Workload) error {
+	namespace := workload.GetNamespace()
+	if namespace == "" {
+		namespace = metaV1.NamespaceDefault
+	}
+
+	var err error
+	switch w := workload.(type) {
+	case *kubernetesManifest.Deployment:
+		if _, err = c.AppsV1().Deployments(namespace).Update(w.Deployment); kubernetesErrors.IsNotFound(err) {
+			_, err = c.AppsV1().Deployments(namespace).Create(w.Deployment)
+		}
+	case *kubernetesManifest.StatefulSet:
+		if _, err = c.AppsV1().StatefulSets(namespace).Update(w.StatefulSet); kubernetesErrors.IsNotFound(err) {
+			_, err = c.AppsV1().StatefulSets(namespace).Create(w.StatefulSet)
+		}
+	case *kubernetesManifest.DaemonSet:
+		if _, err = c.AppsV1().DaemonSets(namespace).Update(w.DaemonSet); kubernetesErrors.IsNotFound(err) {
+			_, err = c.AppsV1().DaemonSets(namespace).Create(w.DaemonSet)
+		}
+	case *kubernetesManifest.ReplicaSet:
+		if _, err = c.AppsV1().ReplicaSets(namespace).Update(w.ReplicaSet); kubernetesErrors.IsNotFound(err) {
+			_, err = c.AppsV1().ReplicaSets(namespace).Create(w.ReplicaSet)
+		}
+	case *kubernetesManifest.CronJob:
+		if _, err = c.BatchV1beta1().CronJobs(namespace).Update(w.CronJob); kubernetesErrors.IsNotFound(err) {
+			_, err = c.BatchV1beta1().CronJobs(namespace).Create(w.CronJob)
+		}
+	case *kubernetesManifest.Job:
+		err = c.replaceJob(namespace, w)
+	default:
+		return ErrApplyingObject{Reasons: []string{
+			fmt.Sprintf("unsupported workload type %T", workload),
+		}}
+	}
+	if kubernetesErrors.IsConflict(err) {
+		err = c.retryOnConflict(workload, err)
+	}
+	if err != nil {
+		return ErrApplyingObject{Reasons: []string{
+			fmt.Sprintf("applying %s '%s'", workload.GetObjectKind().GroupVersionKind().Kind, workload.GetName()),
+			err.Error(),
+		}}
+	}
+	return nil
+}
+
+func (c *Client) replaceJob(namespace string, job *kubernetesManifest.Job) error {
+	jobClient := c.BatchV1().Jobs(namespace)
+
+	// delete the existing job leaving its pods to be garbage collected
+	propagationPolicy := metaV1.DeletePropagationBackground
+	if err := jobClient.Delete(job.Name, &metaV1.DeleteOptions{
+		PropagationPolicy: &propagationPolicy,
+	}); err != nil && !kubernetesErrors.IsNotFound(err) {
+		return err
+	}
+
+	_, err := jobClient.Create(job.Job)
+	return err
+}
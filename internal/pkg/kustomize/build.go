@@ -0,0 +1,100 @@
+package kustomize
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	kubernetesManifest "kubernite/pkg/kubernetes/manifest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+/*
+Build renders the given kustomization with its updated images by running kustomize build.
+kustomize reads the kustomization from disk, so the kustomization directory is copied to a
+temporary directory next to it, in which the updated kustomization is written and built.
+Being next to the original directory, relative paths to bases outside of it still resolve.
+The kustomization directory itself is never changed.
+*/
+func Build(kustomizeBinaryPath string, kustomization *kubernetesManifest.Kustomization) (renderedData []byte, err error) {
+	kustomizationDir := kustomization.Dir()
+	buildDir, err := ioutil.TempDir(filepath.Dir(kustomizationDir), "."+filepath.Base(kustomizationDir)+"-kubernite-")
+	if err != nil {
+		return nil, ErrBuildingKustomization{Reasons: []string{
+			"creating build directory",
+			err.Error(),
+		}}
+	}
+	defer func() {
+		if removeErr := os.RemoveAll(buildDir); removeErr != nil && err == nil {
+			err = ErrBuildingKustomization{Reasons: []string{
+				fmt.Sprintf("removing build directory '%s'", buildDir),
+				removeErr.Error(),
+			}}
+		}
+	}()
+
+	if err := copyDir(kustomizationDir, buildDir); err != nil {
+		return nil, ErrBuildingKustomization{Reasons: []string{
+			fmt.Sprintf("copying '%s' to build directory", kustomizationDir),
+			err.Error(),
+		}}
+	}
+	if err := kustomization.WriteToYAMLAtPath(filepath.Join(buildDir, filepath.Base(kustomization.PathToFile))); err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	command := exec.Command(kustomizeBinaryPath, "build", buildDir)
+	command.Stdout = &stdout
+	command.Stderr = &stderr
+	if err := command.Run(); err != nil {
+		reasons := []string{
+			fmt.Sprintf("running '%s build' for '%s'", kustomizeBinaryPath, kustomizationDir),
+			err.Error(),
+		}
+		if output := strings.TrimSpace(stderr.String()); output != "" {
+			reasons = append(reasons, output)
+		}
+		return nil, ErrBuildingKustomization{Reasons: reasons}
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// copyDir copies the files and directories in sourceDir to targetDir, which must exist
+func copyDir(sourceDir, targetDir string) error {
+	return filepath.Walk(sourceDir, func(sourcePath string, fileInfo os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relativePath, err := filepath.Rel(sourceDir, sourcePath)
+		if err != nil {
+			return err
+		}
+		targetPath := filepath.Join(targetDir, relativePath)
+		switch {
+		case relativePath == ".":
+			return nil
+		case fileInfo.IsDir() && fileInfo.Name() == ".git":
+			return filepath.SkipDir
+		case fileInfo.IsDir():
+			return os.Mkdir(targetPath, fileInfo.Mode().Perm())
+		case fileInfo.Mode()&os.ModeSymlink != 0:
+			linkTarget, err := os.Readlink(sourcePath)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(linkTarget, targetPath)
+		case !fileInfo.Mode().IsRegular():
+			return nil
+		}
+		data, err := ioutil.ReadFile(sourcePath)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(targetPath, data, fileInfo.Mode().Perm())
+	})
+}
@@ -0,0 +1,11 @@
+package kustomize
+
+import "strings"
+
+type ErrBuildingKustomization struct {
+	Reasons []string
+}
+
+func (e ErrBuildingKustomization) Error() string {
+	return "error building kustomization: " + strings.Join(e.Reasons, ", ")
+}
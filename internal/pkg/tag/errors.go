@@ -0,0 +1,27 @@
+package tag
+
+import "strings"
+
+type ErrRenderingTag struct {
+	Reasons []string
+}
+
+func (e ErrRenderingTag) Error() string {
+	return "error rendering image tag: " + strings.Join(e.Reasons, ", ")
+}
+
+type ErrReadingTagsFile struct {
+	Reasons []string
+}
+
+func (e ErrReadingTagsFile) Error() string {
+	return "error reading tags file: " + strings.Join(e.Reasons, ", ")
+}
+
+type ErrSelectingTag struct {
+	Reasons []string
+}
+
+func (e ErrSelectingTag) Error() string {
+	return "error selecting tag: " + strings.Join(e.Reasons, ", ")
+}
@@ -0,0 +1,102 @@
+package tag
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// Source is where the image tag deployed is taken from
+type Source string
+
+const (
+	// GitSource tags are taken from the git repository which triggered the build
+	GitSource Source = "git"
+
+	// FileSource tags are read from a tags file such as the .tags file written
+	// by the drone-docker plugin's auto_tag setting
+	FileSource Source = "file"
+)
+
+// Rule selects one tag from those read from a tags file
+type Rule string
+
+const (
+	// MostSpecificRule selects the tag with the most version components, e.g.
+	// 1.2.3 over 1.2 and 1. The tag 'latest' is only selected if it is the only tag.
+	MostSpecificRule Rule = "most_specific"
+	FirstRule        Rule = "first"
+	LastRule         Rule = "last"
+)
+
+// ReadTagsFile reads the comma or newline separated tags in the file at the given path
+func ReadTagsFile(pathToTagsFile string) ([]string, error) {
+	tagsFileData, err := ioutil.ReadFile(pathToTagsFile)
+	if err != nil {
+		return nil, ErrReadingTagsFile{Reasons: []string{
+			err.Error(),
+		}}
+	}
+
+	var tags []string
+	for _, tag := range strings.FieldsFunc(string(tagsFileData), func(r rune) bool {
+		return r == ',' || r == '\n' || r == '\r'
+	}) {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	if len(tags) == 0 {
+		return nil, ErrReadingTagsFile{Reasons: []string{
+			fmt.Sprintf("no tags in '%s'", pathToTagsFile),
+		}}
+	}
+
+	return tags, nil
+}
+
+// Select selects a tag from the given tags using the given rule
+func Select(tags []string, rule Rule) (string, error) {
+	if len(tags) == 0 {
+		return "", ErrSelectingTag{Reasons: []string{
+			"no tags to select from",
+		}}
+	}
+
+	switch rule {
+	case MostSpecificRule, "":
+		selectedTag := tags[0]
+		for _, tag := range tags[1:] {
+			if moreSpecific(tag, selectedTag) {
+				selectedTag = tag
+			}
+		}
+		return selectedTag, nil
+	case FirstRule:
+		return tags[0], nil
+	case LastRule:
+		return tags[len(tags)-1], nil
+	default:
+		return "", ErrSelectingTag{Reasons: []string{
+			fmt.Sprintf("unknown tag rule '%s'", rule),
+		}}
+	}
+}
+
+// moreSpecific returns true if tag a is more specific than tag b
+func moreSpecific(a, b string) bool {
+	if a == "latest" || b == "latest" {
+		return b == "latest" && a != "latest"
+	}
+	aComponents, bComponents := versionComponents(a), versionComponents(b)
+	if aComponents != bComponents {
+		return aComponents > bComponents
+	}
+	return len(a) > len(b)
+}
+
+func versionComponents(tag string) int {
+	return len(strings.FieldsFunc(tag, func(r rune) bool {
+		return r == '.' || r == '-' || r == '+'
+	}))
+}
@@ -0,0 +1,94 @@
+package tag
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"text/template"
+)
+
+// Strategy determines the image tag deployed for events other than tag events
+type Strategy string
+
+const (
+	LatestStrategy         Strategy = "latest"
+	CommitSHAStrategy      Strategy = "commit_sha"
+	ShortCommitSHAStrategy Strategy = "short_commit_sha"
+	BranchStrategy         Strategy = "branch"
+	BuildNumberStrategy    Strategy = "build_number"
+	TemplateStrategy       Strategy = "template"
+)
+
+var (
+	// validTag matches the tags accepted by docker image registries
+	validTag = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+
+	// invalidTagCharacters matches characters which may not appear in a tag
+	invalidTagCharacters = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+)
+
+// Values are the build values from which an image tag is rendered. The field
+// names are available to tag templates, e.g. '{{.Branch}}-{{.BuildNumber}}'.
+type Values struct {
+	Event          string
+	CommitSHA      string
+	ShortCommitSHA string
+	Branch         string
+	BuildNumber    string
+}
+
+// Render renders the image tag for the given strategy from the given values.
+// The template is only used by the template strategy.
+func Render(strategy Strategy, tagTemplate string, values Values) (string, error) {
+	// branch names such as 'feature/foo' are not valid tags
+	values.Branch = invalidTagCharacters.ReplaceAllString(values.Branch, "-")
+
+	var tag string
+	switch strategy {
+	case LatestStrategy, "":
+		tag = "latest"
+	case CommitSHAStrategy:
+		tag = values.CommitSHA
+	case ShortCommitSHAStrategy:
+		tag = values.ShortCommitSHA
+	case BranchStrategy:
+		tag = values.Branch
+	case BuildNumberStrategy:
+		tag = values.BuildNumber
+	case TemplateStrategy:
+		var err error
+		if tag, err = renderTemplate(tagTemplate, values); err != nil {
+			return "", err
+		}
+	default:
+		return "", ErrRenderingTag{Reasons: []string{
+			fmt.Sprintf("unknown tag strategy '%s'", strategy),
+		}}
+	}
+
+	if !validTag.MatchString(tag) {
+		return "", ErrRenderingTag{Reasons: []string{
+			fmt.Sprintf("'%s' rendered with tag strategy '%s' is not a valid image tag", tag, strategy),
+		}}
+	}
+
+	return tag, nil
+}
+
+func renderTemplate(tagTemplate string, values Values) (string, error) {
+	parsedTemplate, err := template.New("tag").Option("missingkey=error").Parse(tagTemplate)
+	if err != nil {
+		return "", ErrRenderingTag{Reasons: []string{
+			"parsing tag template",
+			err.Error(),
+		}}
+	}
+	var tag bytes.Buffer
+	if err := parsedTemplate.Execute(&tag, values); err != nil {
+		return "", ErrRenderingTag{Reasons: []string{
+			"executing tag template",
+			err.Error(),
+		}}
+	}
+	return tag.String(), nil
+}
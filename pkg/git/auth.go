@@ -0,0 +1,120 @@
+package git
+
+import (
+	"fmt"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+	gitHTTP "gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+	gitSSH "gopkg.in/src-d/go-git.v4/plumbing/transport/ssh"
+	"io/ioutil"
+	"os"
+)
+
+// tokenUsername is sent as the basic auth username when only an access token is
+// given. Git hosts ignore the username when authenticating with a token.
+const tokenUsername = "kubernite"
+
+// newAuth selects ssh or https authentication based on the protocol of the
+// given remote url.
+func newAuth(remoteURL, GitUsername, GitPassword, GitKey, GitKnownHosts string) (transport.AuthMethod, error) {
+	endpoint, err := transport.NewEndpoint(remoteURL)
+	if err != nil {
+		return nil, ErrGitPush{Reasons: []string{
+			fmt.Sprintf("parsing remote url '%s'", remoteURL),
+			err.Error(),
+		}}
+	}
+
+	switch endpoint.Protocol {
+	case "ssh":
+		if GitKey == "" {
+			return nil, ErrGitPush{Reasons: []string{
+				fmt.Sprintf("remote '%s' uses ssh but no git key was given", remoteURL),
+			}}
+		}
+		return newSSHAuth(GitKey, GitKnownHosts)
+
+	case "http", "https":
+		if GitPassword == "" {
+			return nil, ErrGitPush{Reasons: []string{
+				fmt.Sprintf("remote '%s' uses %s but no git password or token was given", remoteURL, endpoint.Protocol),
+			}}
+		}
+		return newHTTPAuth(GitUsername, GitPassword), nil
+
+	default:
+		return nil, ErrGitPush{Reasons: []string{
+			fmt.Sprintf("remote '%s' uses unsupported protocol '%s'", remoteURL, endpoint.Protocol),
+		}}
+	}
+}
+
+// newHTTPAuth creates basic authentication from the given username and
+// password. If no username is given the password is used as an access token.
+func newHTTPAuth(GitUsername, GitPassword string) *gitHTTP.BasicAuth {
+	if GitUsername == "" {
+		GitUsername = tokenUsername
+	}
+	return &gitHTTP.BasicAuth{
+		Username: GitUsername,
+		Password: GitPassword,
+	}
+}
+
+// newSSHAuth creates ssh public key authentication from the given private key.
+// The remote host key is checked against the given known hosts data or, if
+// none is given, against the default known_hosts files.
+func newSSHAuth(GitKey, GitKnownHosts string) (*gitSSH.PublicKeys, error) {
+	auth, err := gitSSH.NewPublicKeys("git", []byte(GitKey), "")
+	if err != nil {
+		return nil, ErrGitPush{Reasons: []string{
+			"parsing git ssh key",
+			err.Error(),
+		}}
+	}
+
+	if GitKnownHosts == "" {
+		auth.HostKeyCallback, err = gitSSH.NewKnownHostsCallback()
+		if err != nil {
+			return nil, ErrGitPush{Reasons: []string{
+				"loading default known hosts",
+				err.Error(),
+			}}
+		}
+		return auth, nil
+	}
+
+	// knownhosts can only be loaded from a file so write the given data to one
+	knownHostsFile, err := ioutil.TempFile("", "kubernite_known_hosts")
+	if err != nil {
+		return nil, ErrGitPush{Reasons: []string{
+			"creating known hosts file",
+			err.Error(),
+		}}
+	}
+	defer func() {
+		_ = os.Remove(knownHostsFile.Name())
+	}()
+	if _, err := knownHostsFile.WriteString(GitKnownHosts); err != nil {
+		_ = knownHostsFile.Close()
+		return nil, ErrGitPush{Reasons: []string{
+			"writing known hosts file",
+			err.Error(),
+		}}
+	}
+	if err := knownHostsFile.Close(); err != nil {
+		return nil, ErrGitPush{Reasons: []string{
+			"closing known hosts file",
+			err.Error(),
+		}}
+	}
+	auth.HostKeyCallback, err = knownhosts.New(knownHostsFile.Name())
+	if err != nil {
+		return nil, ErrGitPush{Reasons: []string{
+			"parsing known hosts",
+			err.Error(),
+		}}
+	}
+
+	return auth, nil
+}
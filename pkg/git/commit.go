@@ -0,0 +1,33 @@
+package git
+
+import (
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"strings"
+)
+
+const shortHashLength = 7
+
+// Commit is a convenience wrapper around a git commit object
+type Commit struct {
+	*object.Commit
+}
+
+// ShortHash returns the abbreviated commit hash
+func (c *Commit) ShortHash() string {
+	return c.Hash.String()[:shortHashLength]
+}
+
+// Subject returns the first line of the commit message
+func (c *Commit) Subject() string {
+	return strings.TrimSpace(strings.SplitN(c.Message, "\n", 2)[0])
+}
+
+// AuthorName returns the name and email of the commit author
+func (c *Commit) AuthorName() string {
+	return c.Author.Name + " <" + c.Author.Email + ">"
+}
+
+// CommitterName returns the name and email of the commit committer
+func (c *Commit) CommitterName() string {
+	return c.Committer.Name + " <" + c.Committer.Email + ">"
+}
@@ -26,13 +26,29 @@ func (e ErrGettingLatestCommit) Error() string {
 	return "error getting latest commit: " + strings.Join(e.Reasons, ", ")
 }
 
+type ErrGettingHeadTags struct {
+	Reasons []string
+}
+
+func (e ErrGettingHeadTags) Error() string {
+	return "error getting HEAD tags: " + strings.Join(e.Reasons, ", ")
+}
+
+type ErrNoHeadTag struct {
+	Hash string
+}
+
+func (e ErrNoHeadTag) Error() string {
+	return "no tag points at HEAD commit " + e.Hash
+}
+
 type ErrNoTags struct{}
 
 func (e ErrNoTags) Error() string {
 	return "no tags"
 }
 
-type ErrGeneratingWorkTree struct{
+type ErrGeneratingWorkTree struct {
 	Reasons []string
 }
 
@@ -40,7 +56,7 @@ func (e ErrGeneratingWorkTree) Error() string {
 	return "error generating git repo worktree: " + strings.Join(e.Reasons, ", ")
 }
 
-type ErrGitAdd struct{
+type ErrGitAdd struct {
 	Reasons []string
 }
 
@@ -48,7 +64,7 @@ func (e ErrGitAdd) Error() string {
 	return "git add error: " + strings.Join(e.Reasons, ", ")
 }
 
-type ErrGitCommit struct{
+type ErrGitCommit struct {
 	Reasons []string
 }
 
@@ -56,7 +72,7 @@ func (e ErrGitCommit) Error() string {
 	return "git commit error: " + strings.Join(e.Reasons, ", ")
 }
 
-type ErrGitPush struct{
+type ErrGitPush struct {
 	Reasons []string
 }
 
@@ -64,10 +80,10 @@ func (e ErrGitPush) Error() string {
 	return "git push error: " + strings.Join(e.Reasons, ", ")
 }
 
-type ErrGeneratingRelFilePath struct{
+type ErrGeneratingRelFilePath struct {
 	Reasons []string
 }
 
 func (e ErrGeneratingRelFilePath) Error() string {
 	return "error generating relative file path: " + strings.Join(e.Reasons, ", ")
-}
\ No newline at end of file
+}
@@ -0,0 +1,39 @@
+package git
+
+// Event is a drone build event
+type Event string
+
+func (e Event) String() string {
+	return string(e)
+}
+
+const (
+	PushEvent        Event = "push"
+	PullRequestEvent Event = "pull_request"
+	TagEvent         Event = "tag"
+	PromoteEvent     Event = "promote"
+	RollbackEvent    Event = "rollback"
+	CronEvent        Event = "cron"
+	CustomEvent      Event = "custom"
+)
+
+// Events are all of the drone build events
+var Events = []Event{
+	PushEvent,
+	PullRequestEvent,
+	TagEvent,
+	PromoteEvent,
+	RollbackEvent,
+	CronEvent,
+	CustomEvent,
+}
+
+// IsValid returns true if the event is a known drone build event
+func (e Event) IsValid() bool {
+	for _, event := range Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
@@ -3,22 +3,18 @@ package git
 import (
 	"fmt"
 	goGit "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/config"
 	"gopkg.in/src-d/go-git.v4/plumbing"
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
-	"io"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 )
 
-type Event string
-
-func (e Event) String() string {
-	return string(e)
-}
-
-const TagEvent Event = "tag"
-
 type Repository struct {
 	goGit.Repository
 }
@@ -63,74 +59,126 @@ func NewRepositoryFromFilePath(pathToRepository string) (*Repository, error) {
 	}, nil
 }
 
-func (r *Repository) GetLatestTagName() (string, error) {
-	latestTag, err := r.GetLatestTag()
+func (r *Repository) GetLatestTagName(tagPrefix, tagPattern string) (string, error) {
+	latestTag, err := r.GetLatestTag(tagPrefix, tagPattern)
 	if err != nil {
 		return "", err
 	}
 	return latestTag.Name().Short(), nil
 }
 
-func (r *Repository) GetLatestTag() (*plumbing.Reference, error) {
-	tagReferenceIterator, err := r.Tags()
+// GetLatestTag returns the tag with the highest semantic version. Only tags
+// starting with the given prefix and matching the given pattern are
+// considered. If none of these tags are semantic versions the tag pointing at
+// the most recent commit is returned.
+func (r *Repository) GetLatestTag(tagPrefix, tagPattern string) (*plumbing.Reference, error) {
+	tags, err := r.filterTags(tagPrefix, tagPattern, nil)
 	if err != nil {
 		return nil, ErrGettingLatestTag{Reasons: []string{
 			err.Error(),
 		}}
 	}
-	if tagReferenceIterator == nil {
+	if len(tags) == 0 {
 		return nil, ErrNoTags{}
 	}
 
-	latestTag, err := tagReferenceIterator.Next()
-	switch err {
-	case io.EOF:
-		// no tags
-		return nil, ErrNoTags{}
-	case nil:
-		return latestTag, nil
-	default:
-		return nil, ErrGettingLatestTag{Reasons: []string{
-			"getting next tag",
+	sort.Sort(byVersion(tags))
+	return tags[len(tags)-1].Reference, nil
+}
+
+// GetHeadTags returns the tags which point at the commit checked out at HEAD.
+// Only tags starting with the given prefix and matching the given pattern are
+// returned. The tags are ordered from lowest to highest version.
+func (r *Repository) GetHeadTags(tagPrefix, tagPattern string) ([]*plumbing.Reference, error) {
+	head, err := r.Head()
+	if err != nil {
+		return nil, ErrGettingHeadTags{Reasons: []string{
+			"getting HEAD",
+			err.Error(),
+		}}
+	}
+
+	tags, err := r.filterTags(tagPrefix, tagPattern, func(t *tag) bool {
+		return t.Commit.Hash == head.Hash()
+	})
+	if err != nil {
+		return nil, ErrGettingHeadTags{Reasons: []string{
 			err.Error(),
 		}}
 	}
+	if len(tags) == 0 {
+		return nil, ErrNoHeadTag{Hash: head.Hash().String()}
+	}
+
+	sort.Sort(byVersion(tags))
+	tagReferences := make([]*plumbing.Reference, len(tags))
+	for i := range tags {
+		tagReferences[i] = tags[i].Reference
+	}
+	return tagReferences, nil
 }
 
-func (r *Repository) GetLatestCommitHash() (string, error) {
-	latestCommit, err := r.GetLatestCommit()
+func (r *Repository) GetLatestCommitHash(revision string) (string, error) {
+	latestCommit, err := r.GetLatestCommit(revision)
 	if err != nil {
 		return "", err
 	}
 	return latestCommit.Hash.String(), nil
 }
 
-func (r *Repository) GetLatestCommit() (*object.Commit, error) {
-	commitReferenceIterator, err := r.CommitObjects()
+// GetLatestCommit returns the commit at the given revision, which may be a
+// branch, tag or any other revision understood by git. HEAD is used if no
+// revision is given.
+func (r *Repository) GetLatestCommit(revision string) (*Commit, error) {
+	if revision == "" {
+		revision = plumbing.HEAD.String()
+	}
+
+	commitHash, err := r.ResolveRevision(plumbing.Revision(revision))
 	if err != nil {
 		return nil, ErrGettingLatestCommit{Reasons: []string{
-			"getting commit iterator",
+			fmt.Sprintf("resolving revision '%s'", revision),
 			err.Error(),
 		}}
 	}
-	commit, err := commitReferenceIterator.Next()
+	commit, err := r.CommitObject(*commitHash)
 	if err != nil {
 		return nil, ErrGettingLatestCommit{Reasons: []string{
-			"getting next commit",
+			fmt.Sprintf("getting commit %s", commitHash),
 			err.Error(),
 		}}
 	}
-	return commit, nil
+	return &Commit{Commit: commit}, nil
 }
 
 func (r *Repository) CommitDeployment(
 	DeploymentFileRepositoryPath,
-	DeploymentFilePath string,
-	//TODO - used for git push
-	//GitUsername,
-	//GitPassword,
-	//GitKey string,
-	) error {
+	DeploymentFilePath,
+	GitRemoteName,
+	GitBranch,
+	GitUsername,
+	GitPassword,
+	GitKey,
+	GitKnownHosts string,
+) error {
+	// select push authentication before anything is committed
+	remote, err := r.Remote(GitRemoteName)
+	if err != nil {
+		return ErrGitPush{Reasons: []string{
+			fmt.Sprintf("getting remote '%s'", GitRemoteName),
+			err.Error(),
+		}}
+	}
+	if len(remote.Config().URLs) == 0 {
+		return ErrGitPush{Reasons: []string{
+			fmt.Sprintf("remote '%s' has no url", GitRemoteName),
+		}}
+	}
+	auth, err := newAuth(remote.Config().URLs[0], GitUsername, GitPassword, GitKey, GitKnownHosts)
+	if err != nil {
+		return err
+	}
+
 	// get worktree
 	w, err := r.Worktree()
 	if err != nil {
@@ -176,25 +224,95 @@ func (r *Repository) CommitDeployment(
 		}}
 	}
 
-	// TODO - implement git push
-	//signer, err := ssh.ParsePrivateKey([]byte(GitKey))
-	//auth := &gitssh.PublicKeys{User: "git", Signer: signer}
-	//
-	//// git push kubernite deployment
-	//if err := r.Push(&goGit.PushOptions{
-	//	RemoteName: "origin",
-	//	RefSpecs:   nil,
-	//	Auth:       auth,
-	//	Progress:   nil,
-	//	Prune:      false,
-	//}); err != nil {
-	//	return ErrGitPush{
-	//		Reasons: []string{
-	//			"git push deployment",
-	//			err.Error(),
-	//		},
-	//	}
-	//}
+	// git push kubernite deployment
+	if err := r.push(GitRemoteName, GitBranch, auth); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (r *Repository) push(remoteName, branch string, auth transport.AuthMethod) error {
+	// the branch checked out is pushed
+	head, err := r.Head()
+	if err != nil {
+		return ErrGitPush{Reasons: []string{
+			"getting HEAD",
+			err.Error(),
+		}}
+	}
+	if !head.Name().IsBranch() {
+		return ErrGitPush{Reasons: []string{
+			"HEAD is not on a branch",
+		}}
+	}
+	if branch == "" {
+		branch = head.Name().Short()
+	}
+
+	if err := r.Push(&goGit.PushOptions{
+		RemoteName: remoteName,
+		RefSpecs: []config.RefSpec{
+			config.RefSpec(fmt.Sprintf("%s:%s", head.Name(), plumbing.NewBranchReferenceName(branch))),
+		},
+		Auth: auth,
+	}); err != nil {
+		switch err {
+		case goGit.NoErrAlreadyUpToDate:
+			return nil
+		case transport.ErrAuthenticationRequired, transport.ErrAuthorizationFailed:
+			return ErrGitPush{Reasons: []string{
+				fmt.Sprintf("authentication to remote '%s' failed using %s", remoteName, auth.Name()),
+				"check the configured git credentials",
+				err.Error(),
+			}}
+		default:
+			return ErrGitPush{Reasons: []string{
+				fmt.Sprintf("git push deployment to %s/%s", remoteName, branch),
+				err.Error(),
+			}}
+		}
+	}
 
 	return nil
 }
+
+// filterTags returns the tags starting with the given prefix and matching the
+// given pattern for which keep returns true. All tags are kept if keep is nil.
+func (r *Repository) filterTags(tagPrefix, tagPattern string, keep func(t *tag) bool) ([]*tag, error) {
+	var tagRegexp *regexp.Regexp
+	if tagPattern != "" {
+		var err error
+		if tagRegexp, err = regexp.Compile(tagPattern); err != nil {
+			return nil, fmt.Errorf("compiling tag pattern '%s': %s", tagPattern, err)
+		}
+	}
+
+	tagReferenceIterator, err := r.Tags()
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []*tag
+	if err := tagReferenceIterator.ForEach(func(tagReference *plumbing.Reference) error {
+		tagName := tagReference.Name().Short()
+		if !strings.HasPrefix(tagName, tagPrefix) {
+			return nil
+		}
+		if tagRegexp != nil && !tagRegexp.MatchString(tagName) {
+			return nil
+		}
+		t, err := r.newTag(tagReference, tagPrefix)
+		if err != nil {
+			return err
+		}
+		if keep == nil || keep(t) {
+			tags = append(tags, t)
+		}
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("iterating over tags: %s", err)
+	}
+
+	return tags, nil
+}
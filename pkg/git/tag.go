@@ -0,0 +1,77 @@
+package git
+
+import (
+	"github.com/Masterminds/semver"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"strings"
+)
+
+// tag is a tag reference along with the information used to order it
+type tag struct {
+	Reference *plumbing.Reference
+	Commit    *object.Commit
+	Version   *semver.Version
+}
+
+func (r *Repository) newTag(tagReference *plumbing.Reference, tagPrefix string) (*tag, error) {
+	commit, err := r.peelTag(tagReference)
+	if err != nil {
+		return nil, err
+	}
+
+	newTag := &tag{
+		Reference: tagReference,
+		Commit:    commit,
+	}
+
+	// tags which are not semantic versions are left without a version
+	if version, err := semver.NewVersion(strings.TrimPrefix(tagReference.Name().Short(), tagPrefix)); err == nil {
+		newTag.Version = version
+	}
+
+	return newTag, nil
+}
+
+// byVersion orders tags by semantic version. Tags which are not semantic
+// versions are ordered by commit date and come before all semantic versions.
+type byVersion []*tag
+
+func (t byVersion) Len() int {
+	return len(t)
+}
+
+func (t byVersion) Swap(i, j int) {
+	t[i], t[j] = t[j], t[i]
+}
+
+func (t byVersion) Less(i, j int) bool {
+	switch {
+	case t[i].Version != nil && t[j].Version != nil:
+		if !t[i].Version.Equal(t[j].Version) {
+			return t[i].Version.LessThan(t[j].Version)
+		}
+		return t[i].Commit.Committer.When.Before(t[j].Commit.Committer.When)
+	case t[i].Version != nil:
+		return false
+	case t[j].Version != nil:
+		return true
+	default:
+		return t[i].Commit.Committer.When.Before(t[j].Commit.Committer.When)
+	}
+}
+
+// peelTag returns the commit a tag points at. Annotated tags are peeled to the
+// commit they tag.
+func (r *Repository) peelTag(tagReference *plumbing.Reference) (*object.Commit, error) {
+	tagObject, err := r.TagObject(tagReference.Hash())
+	switch err {
+	case nil:
+		return tagObject.Commit()
+	case plumbing.ErrObjectNotFound:
+		// lightweight tags point directly at a commit
+		return r.CommitObject(tagReference.Hash())
+	default:
+		return nil, err
+	}
+}
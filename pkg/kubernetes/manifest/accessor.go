@@ -0,0 +1,199 @@
+package manifest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+/*
+accessorElement is a single step of an accessor path. It is either a mapping key, a
+sequence index or a sequence selector which selects the mapping in a sequence with the
+given key set to the given value.
+*/
+type accessorElement struct {
+	Key           string
+	Index         int
+	IsIndex       bool
+	SelectorKey   string
+	SelectorValue string
+	IsSelector    bool
+}
+
+/*
+parseAccessorPath parses an accessor path such as
+
+	spec.template.spec.containers[name=api].image
+	spec.template.spec.containers[0].image
+	metadata.annotations["kubernetes.io/change-cause"]
+
+Keys which contain '.' or '[' must be quoted in brackets.
+*/
+func parseAccessorPath(accessorPath string) ([]accessorElement, error) {
+	var elements []accessorElement
+	remainder := accessorPath
+	for remainder != "" {
+		switch remainder[0] {
+		case '.':
+			if len(elements) == 0 || len(remainder) == 1 {
+				return nil, fmt.Errorf("unexpected '.'")
+			}
+			remainder = remainder[1:]
+			if remainder[0] == '.' || remainder[0] == '[' {
+				return nil, fmt.Errorf("expected key after '.'")
+			}
+
+		case '[':
+			end := strings.IndexByte(remainder, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("unclosed '['")
+			}
+			element, err := parseBracketElement(remainder[1:end])
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, element)
+			remainder = remainder[end+1:]
+
+		default:
+			if len(elements) > 0 && !strings.HasPrefix(accessorPath[len(accessorPath)-len(remainder)-1:], ".") {
+				return nil, fmt.Errorf("expected '.' or '[' before '%s'", remainder)
+			}
+			end := strings.IndexAny(remainder, ".[")
+			if end == -1 {
+				end = len(remainder)
+			}
+			elements = append(elements, accessorElement{Key: remainder[:end]})
+			remainder = remainder[end:]
+		}
+	}
+	if len(elements) == 0 {
+		return nil, fmt.Errorf("accessor path is blank")
+	}
+	return elements, nil
+}
+
+// parseBracketElement parses the contents of [...] as a quoted key, index or selector
+func parseBracketElement(bracketContents string) (accessorElement, error) {
+	if strings.HasPrefix(bracketContents, `"`) || strings.HasPrefix(bracketContents, `'`) {
+		key, err := unquote(bracketContents)
+		if err != nil {
+			return accessorElement{}, err
+		}
+		return accessorElement{Key: key}, nil
+	}
+	if i := strings.IndexByte(bracketContents, '='); i != -1 {
+		selectorValue, err := unquote(strings.TrimSpace(bracketContents[i+1:]))
+		if err != nil {
+			return accessorElement{}, err
+		}
+		return accessorElement{
+			SelectorKey:   strings.TrimSpace(bracketContents[:i]),
+			SelectorValue: selectorValue,
+			IsSelector:    true,
+		}, nil
+	}
+	index, err := strconv.Atoi(bracketContents)
+	if err != nil || index < 0 {
+		return accessorElement{}, fmt.Errorf("'[%s]' is not a quoted key, index or selector", bracketContents)
+	}
+	return accessorElement{Index: index, IsIndex: true}, nil
+}
+
+func unquote(value string) (string, error) {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') {
+		if value[len(value)-1] != value[0] {
+			return "", fmt.Errorf("unclosed quote in '%s'", value)
+		}
+		return value[1 : len(value)-1], nil
+	}
+	return value, nil
+}
+
+/*
+setField sets the field at the given accessor path in the given unstructured object to
+the given value, creating mappings which do not exist. It returns the yaml path of the
+field.
+*/
+func setField(object map[string]interface{}, objectName, accessorPath string, value interface{}) (yamlPath, error) {
+	elements, err := parseAccessorPath(accessorPath)
+	if err != nil {
+		return nil, ErrInvalidAccessorPath{AccessorPath: accessorPath, Object: err.Error()}
+	}
+
+	var path yamlPath
+	var current interface{} = object
+	for i, element := range elements {
+		last := i == len(elements)-1
+		switch {
+		case element.IsIndex || element.IsSelector:
+			sequence, isSequence := current.([]interface{})
+			if !isSequence {
+				return nil, ErrInvalidAccessorPath{AccessorPath: accessorPath, Object: objectName}
+			}
+			index := element.Index
+			if element.IsSelector {
+				index = selectIndex(sequence, element.SelectorKey, element.SelectorValue)
+			}
+			if index < 0 || index >= len(sequence) {
+				return nil, ErrKeyNotFoundInObject{Key: path.String() + elementString(element), Object: objectName}
+			}
+			if element.IsSelector {
+				path = append(path, sequenceSelector{Key: element.SelectorKey, Value: element.SelectorValue})
+			} else {
+				path = append(path, index)
+			}
+			if last {
+				sequence[index] = value
+			} else {
+				current = sequence[index]
+			}
+
+		default:
+			mapping, isMapping := current.(map[string]interface{})
+			if !isMapping {
+				return nil, ErrInvalidAccessorPath{AccessorPath: accessorPath, Object: objectName}
+			}
+			path = append(path, element.Key)
+			if last {
+				mapping[element.Key] = value
+				break
+			}
+			next, found := mapping[element.Key]
+			if !found || next == nil {
+				// only mappings can be created, sequence elements must already exist
+				if elements[i+1].IsIndex || elements[i+1].IsSelector {
+					return nil, ErrKeyNotFoundInObject{Key: path.String(), Object: objectName}
+				}
+				next = make(map[string]interface{})
+				mapping[element.Key] = next
+			}
+			current = next
+		}
+	}
+
+	return path, nil
+}
+
+// selectIndex returns the index of the mapping in the sequence with the given key set to the given value
+func selectIndex(sequence []interface{}, selectorKey, selectorValue string) int {
+	for i, item := range sequence {
+		if mapping, isMapping := item.(map[string]interface{}); isMapping {
+			if itemValue, found := mapping[selectorKey]; found && fmt.Sprint(itemValue) == selectorValue {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func elementString(element accessorElement) string {
+	switch {
+	case element.IsSelector:
+		return fmt.Sprintf("[%s=%s]", element.SelectorKey, element.SelectorValue)
+	case element.IsIndex:
+		return fmt.Sprintf("[%d]", element.Index)
+	default:
+		return "." + element.Key
+	}
+}
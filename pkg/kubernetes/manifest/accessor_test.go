@@ -0,0 +1,191 @@
+package manifest
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseAccessorPath(t *testing.T) {
+	tests := []struct {
+		accessorPath string
+		want         []accessorElement
+	}{
+		{
+			accessorPath: "spec",
+			want:         []accessorElement{{Key: "spec"}},
+		},
+		{
+			accessorPath: "spec.values.image.tag",
+			want:         []accessorElement{{Key: "spec"}, {Key: "values"}, {Key: "image"}, {Key: "tag"}},
+		},
+		{
+			accessorPath: "spec.template.spec.containers[0].image",
+			want: []accessorElement{
+				{Key: "spec"}, {Key: "template"}, {Key: "spec"}, {Key: "containers"},
+				{Index: 0, IsIndex: true},
+				{Key: "image"},
+			},
+		},
+		{
+			accessorPath: "spec.template.spec.containers[name=api].image",
+			want: []accessorElement{
+				{Key: "spec"}, {Key: "template"}, {Key: "spec"}, {Key: "containers"},
+				{SelectorKey: "name", SelectorValue: "api", IsSelector: true},
+				{Key: "image"},
+			},
+		},
+		{
+			accessorPath: `containers[name="api.v2"].image`,
+			want: []accessorElement{
+				{Key: "containers"},
+				{SelectorKey: "name", SelectorValue: "api.v2", IsSelector: true},
+				{Key: "image"},
+			},
+		},
+		{
+			accessorPath: `metadata.annotations["kubernetes.io/change-cause"]`,
+			want:         []accessorElement{{Key: "metadata"}, {Key: "annotations"}, {Key: "kubernetes.io/change-cause"}},
+		},
+		{
+			accessorPath: `metadata.labels['app.kubernetes.io/version']`,
+			want:         []accessorElement{{Key: "metadata"}, {Key: "labels"}, {Key: "app.kubernetes.io/version"}},
+		},
+		{
+			accessorPath: "matrix[1][2]",
+			want:         []accessorElement{{Key: "matrix"}, {Index: 1, IsIndex: true}, {Index: 2, IsIndex: true}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.accessorPath, func(t *testing.T) {
+			elements, err := parseAccessorPath(test.accessorPath)
+			if err != nil {
+				t.Fatalf("parseAccessorPath(%q) returned error: %s", test.accessorPath, err)
+			}
+			if !reflect.DeepEqual(elements, test.want) {
+				t.Errorf("parseAccessorPath(%q) = %+v, want %+v", test.accessorPath, elements, test.want)
+			}
+		})
+	}
+}
+
+func TestParseAccessorPathMalformed(t *testing.T) {
+	tests := []struct {
+		name         string
+		accessorPath string
+	}{
+		{name: "blank", accessorPath: ""},
+		{name: "leading dot", accessorPath: ".spec"},
+		{name: "trailing dot", accessorPath: "spec."},
+		{name: "double dot", accessorPath: "spec..replicas"},
+		{name: "dot before bracket", accessorPath: "containers.[0]"},
+		{name: "unclosed bracket", accessorPath: "containers[0"},
+		{name: "negative index", accessorPath: "containers[-1]"},
+		{name: "not an index", accessorPath: "containers[first]"},
+		{name: "key after bracket without dot", accessorPath: "containers[0]image"},
+		{name: "unclosed quote", accessorPath: `annotations["kubernetes.io/change-cause]`},
+		{name: "unclosed quote in selector", accessorPath: `containers[name="api].image`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if elements, err := parseAccessorPath(test.accessorPath); err == nil {
+				t.Errorf("parseAccessorPath(%q) = %+v, want error", test.accessorPath, elements)
+			}
+		})
+	}
+}
+
+func TestSetField(t *testing.T) {
+	newObject := func() map[string]interface{} {
+		return map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "api"},
+			"spec": map[string]interface{}{
+				"containers": []interface{}{
+					map[string]interface{}{"name": "api", "image": "api:1"},
+					map[string]interface{}{"name": "proxy", "image": "proxy:1"},
+				},
+			},
+		}
+	}
+	tests := []struct {
+		accessorPath string
+		value        interface{}
+		wantPath     yamlPath
+		get          func(object map[string]interface{}) interface{}
+	}{
+		{
+			accessorPath: "spec.containers[1].image",
+			value:        "proxy:2",
+			wantPath:     yamlPath{"spec", "containers", 1, "image"},
+			get: func(object map[string]interface{}) interface{} {
+				return object["spec"].(map[string]interface{})["containers"].([]interface{})[1].(map[string]interface{})["image"]
+			},
+		},
+		{
+			accessorPath: "spec.containers[name=proxy].image",
+			value:        "proxy:2",
+			wantPath:     yamlPath{"spec", "containers", sequenceSelector{Key: "name", Value: "proxy"}, "image"},
+			get: func(object map[string]interface{}) interface{} {
+				return object["spec"].(map[string]interface{})["containers"].([]interface{})[1].(map[string]interface{})["image"]
+			},
+		},
+		{
+			accessorPath: `metadata.annotations["kubernetes.io/change-cause"]`,
+			value:        "initial deploy",
+			wantPath:     yamlPath{"metadata", "annotations", "kubernetes.io/change-cause"},
+			get: func(object map[string]interface{}) interface{} {
+				return object["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})["kubernetes.io/change-cause"]
+			},
+		},
+		{
+			accessorPath: "spec.replicas",
+			value:        int64(3),
+			wantPath:     yamlPath{"spec", "replicas"},
+			get: func(object map[string]interface{}) interface{} {
+				return object["spec"].(map[string]interface{})["replicas"]
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.accessorPath, func(t *testing.T) {
+			object := newObject()
+			path, err := setField(object, "api", test.accessorPath, test.value)
+			if err != nil {
+				t.Fatalf("setField(%q) returned error: %s", test.accessorPath, err)
+			}
+			if !reflect.DeepEqual(path, test.wantPath) {
+				t.Errorf("setField(%q) path = %v, want %v", test.accessorPath, path, test.wantPath)
+			}
+			if got := test.get(object); got != test.value {
+				t.Errorf("field at %q = %v, want %v", test.accessorPath, got, test.value)
+			}
+		})
+	}
+}
+
+func TestSetFieldInvalid(t *testing.T) {
+	tests := []struct {
+		name         string
+		accessorPath string
+	}{
+		{name: "malformed path", accessorPath: "spec..replicas"},
+		{name: "index of a mapping", accessorPath: "metadata[0]"},
+		{name: "key of a sequence", accessorPath: "spec.containers.image"},
+		{name: "key of a scalar", accessorPath: "metadata.name.first"},
+		{name: "index out of range", accessorPath: "spec.containers[2].image"},
+		{name: "missing selected element", accessorPath: "spec.containers[name=sidecar].image"},
+		{name: "missing sequence", accessorPath: "spec.volumes[0].name"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			object := map[string]interface{}{
+				"metadata": map[string]interface{}{"name": "api"},
+				"spec": map[string]interface{}{
+					"containers": []interface{}{map[string]interface{}{"name": "api"}},
+				},
+			}
+			if _, err := setField(object, "api", test.accessorPath, "x"); err == nil {
+				t.Errorf("setField(%q) returned no error", test.accessorPath)
+			}
+		})
+	}
+}
@@ -0,0 +1,257 @@
+package manifest
+
+import (
+	"fmt"
+	coreV1 "k8s.io/api/core/v1"
+	"regexp"
+	"strings"
+)
+
+/*
+ImageUpdate sets the tag of every container selected by all of the given selectors:
+
+	ImageName     the container runs the image with this name
+	ContainerName the container has this name
+	ImagePattern  the image of the container matches this regular expression
+	MarkerKey     the container is named in the comma separated value of the pod
+	              template annotation or label with this key
+
+If no selector is given all containers in the pod template must run the same image.
+*/
+type ImageUpdate struct {
+	ImageName     string
+	ContainerName string
+	ImagePattern  string
+	MarkerKey     string
+	Tag           string
+}
+
+func (u ImageUpdate) String() string {
+	var selectors []string
+	if u.ImageName != "" {
+		selectors = append(selectors, fmt.Sprintf("image name '%s'", u.ImageName))
+	}
+	if u.ContainerName != "" {
+		selectors = append(selectors, fmt.Sprintf("container name '%s'", u.ContainerName))
+	}
+	if u.ImagePattern != "" {
+		selectors = append(selectors, fmt.Sprintf("image pattern '%s'", u.ImagePattern))
+	}
+	if u.MarkerKey != "" {
+		selectors = append(selectors, fmt.Sprintf("marker '%s'", u.MarkerKey))
+	}
+	return strings.Join(selectors, " and ")
+}
+
+func (u ImageUpdate) hasSelector() bool {
+	return u.ImageName != "" || u.ContainerName != "" || u.ImagePattern != "" || u.MarkerKey != ""
+}
+
+// containerSelector returns true for the containers selected by the given image update
+type containerSelector func(c podContainer, containerImage *ImageReference) bool
+
+/*
+newContainerSelector creates a selector which selects the containers matching all the
+selectors of the given image update
+*/
+func (w *podTemplateWorkload) newContainerSelector(u ImageUpdate) (containerSelector, error) {
+	var image *ImageReference
+	if u.ImageName != "" {
+		var err error
+		if image, err = ParseImageReference(u.ImageName); err != nil {
+			return nil, err
+		}
+	}
+
+	var imageRegexp *regexp.Regexp
+	if u.ImagePattern != "" {
+		var err error
+		if imageRegexp, err = regexp.Compile(u.ImagePattern); err != nil {
+			return nil, ErrInvalidContainerSelector{Reasons: []string{
+				fmt.Sprintf("compiling image pattern '%s'", u.ImagePattern),
+				err.Error(),
+			}}
+		}
+	}
+
+	var markedContainers map[string]bool
+	if u.MarkerKey != "" {
+		marker, found := w.podTemplate.Annotations[u.MarkerKey]
+		if !found {
+			marker, found = w.podTemplate.Labels[u.MarkerKey]
+		}
+		if !found {
+			return nil, ErrInvalidContainerSelector{Reasons: []string{
+				fmt.Sprintf("no annotation or label '%s' in pod template of '%s'", u.MarkerKey, w.objectMeta.Name),
+			}}
+		}
+		markedContainers = make(map[string]bool)
+		for _, containerName := range strings.Split(marker, ",") {
+			markedContainers[strings.TrimSpace(containerName)] = true
+		}
+	}
+
+	return func(c podContainer, containerImage *ImageReference) bool {
+		if image != nil && !containerImage.SameRepository(image) {
+			return false
+		}
+		if u.ContainerName != "" && c.container.Name != u.ContainerName {
+			return false
+		}
+		if imageRegexp != nil && !imageRegexp.MatchString(c.container.Image) {
+			return false
+		}
+		if markedContainers != nil && !markedContainers[c.container.Name] {
+			return false
+		}
+		return true
+	}, nil
+}
+
+/*
+ContainerUpdate reports the image of a container which was changed
+*/
+type ContainerUpdate struct {
+	// ContainerType is the pod spec field holding the container, i.e. containers or initContainers
+	ContainerType string
+	ContainerName string
+	PreviousImage string
+	Image         string
+}
+
+func (c ContainerUpdate) String() string {
+	return fmt.Sprintf("%s %s: %s -> %s", c.ContainerType, c.ContainerName, c.PreviousImage, c.Image)
+}
+
+// podContainer is a container in the pod template along with the pod spec field it is in
+type podContainer struct {
+	containerType string
+	container     *coreV1.Container
+}
+
+// podContainers returns the init containers and containers of the pod template
+func (w *podTemplateWorkload) podContainers() []podContainer {
+	var podContainers []podContainer
+	for i := range w.podTemplate.Spec.InitContainers {
+		podContainers = append(podContainers, podContainer{
+			containerType: "initContainers",
+			container:     &w.podTemplate.Spec.InitContainers[i],
+		})
+	}
+	for i := range w.podTemplate.Spec.Containers {
+		podContainers = append(podContainers, podContainer{
+			containerType: "containers",
+			container:     &w.podTemplate.Spec.Containers[i],
+		})
+	}
+	return podContainers
+}
+
+/*
+UpdateImageTag sets the tag of the container image with the given name. The image name
+may be left blank if the pod template has only one container.
+*/
+func (w *podTemplateWorkload) UpdateImageTag(imageName, latestTag string) error {
+	_, err := w.UpdateImageTags([]ImageUpdate{{ImageName: imageName, Tag: latestTag}})
+	return err
+}
+
+/*
+UpdateImageTags sets the tag of the containers and init containers selected by each of
+the given image updates. It returns the containers whose image changed.
+*/
+func (w *podTemplateWorkload) UpdateImageTags(imageUpdates []ImageUpdate) ([]ContainerUpdate, error) {
+	podContainers := w.podContainers()
+
+	// validation
+	if len(podContainers) == 0 {
+		return nil, ErrManifestInvalid{
+			Reasons: []string{
+				fmt.Sprintf("no images in pod spec of '%s'", w.objectMeta.Name),
+			},
+		}
+	}
+
+	// parse the images of all containers up front so that no container is updated if one is invalid
+	containerImages := make([]*ImageReference, len(podContainers))
+	for i, c := range podContainers {
+		containerImage, err := ParseImageReference(c.container.Image)
+		if err != nil {
+			return nil, err
+		}
+		containerImages[i] = containerImage
+	}
+
+	var containerUpdates []ContainerUpdate
+	for _, imageUpdate := range imageUpdates {
+		if !imageUpdate.hasSelector() {
+			for _, containerImage := range containerImages[1:] {
+				if !containerImage.SameRepository(containerImages[0]) {
+					return nil, ErrImageNotSpecified{CandidateImages: candidateImages(podContainers)}
+				}
+			}
+		}
+		selectContainer, err := w.newContainerSelector(imageUpdate)
+		if err != nil {
+			return nil, err
+		}
+
+		matched := false
+		for i, c := range podContainers {
+			if !selectContainer(c, containerImages[i]) {
+				continue
+			}
+			matched = true
+			updatedImage, err := containerImages[i].WithTag(imageUpdate.Tag)
+			if err != nil {
+				return nil, err
+			}
+			if updatedImage.String() == c.container.Image {
+				continue
+			}
+			containerUpdates = append(containerUpdates, ContainerUpdate{
+				ContainerType: c.containerType,
+				ContainerName: c.container.Name,
+				PreviousImage: c.container.Image,
+				Image:         updatedImage.String(),
+			})
+			c.container.Image = updatedImage.String()
+			w.recordEdit(
+				w.podTemplateField("spec", c.containerType, sequenceSelector{Key: "name", Value: c.container.Name}, "image"),
+				updatedImage.String(),
+			)
+		}
+		if !matched {
+			return nil, ErrSuppliedImageNameNotInConfigFile{
+				Selector:        imageUpdate.String(),
+				CandidateImages: candidateImages(podContainers),
+			}
+		}
+	}
+
+	return containerUpdates, nil
+}
+
+/*
+RecordImages records the image of every container and init container as a change made to
+the workload, e.g. for a workload rendered by kustomize whose images were set by rendering
+rather than by UpdateImageTags, so that the images are also set when the changes are made
+to the live object.
+*/
+func (w *podTemplateWorkload) RecordImages() {
+	for _, c := range w.podContainers() {
+		w.recordEdit(
+			w.podTemplateField("spec", c.containerType, sequenceSelector{Key: "name", Value: c.container.Name}, "image"),
+			c.container.Image,
+		)
+	}
+}
+
+// candidateImages lists the containers and their images for error messages
+func candidateImages(podContainers []podContainer) []string {
+	images := make([]string, len(podContainers))
+	for i, c := range podContainers {
+		images[i] = fmt.Sprintf("%s=%s", c.container.Name, c.container.Image)
+	}
+	return images
+}
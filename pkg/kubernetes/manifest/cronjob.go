@@ -0,0 +1,30 @@
+package manifest
+
+import (
+	batchV1Beta1 "k8s.io/api/batch/v1beta1"
+)
+
+/*
+CronJob is a convenience wrapper around the manifest object type that represents a cronjob.
+The pod template is that of the job template.
+*/
+type CronJob struct {
+	*batchV1Beta1.CronJob
+	podTemplateWorkload
+}
+
+func newCronJobFromData(cronjobData []byte, pathToCronJobFile string) (*CronJob, error) {
+	newCronJob := &CronJob{CronJob: new(batchV1Beta1.CronJob)}
+	if err := decodeWorkload(cronjobData, newCronJob.CronJob); err != nil {
+		return nil, err
+	}
+	newCronJob.podTemplateWorkload = newPodTemplateWorkload(
+		cronjobData,
+		pathToCronJobFile,
+		newCronJob.CronJob,
+		&newCronJob.ObjectMeta,
+		&newCronJob.Spec.JobTemplate.Spec.Template,
+		yamlPath{"spec", "jobTemplate", "spec", "template"},
+	)
+	return newCronJob, nil
+}
@@ -0,0 +1,29 @@
+package manifest
+
+import (
+	appsV1 "k8s.io/api/apps/v1"
+)
+
+/*
+DaemonSet is a convenience wrapper around the manifest object type that represents a daemonset
+*/
+type DaemonSet struct {
+	*appsV1.DaemonSet
+	podTemplateWorkload
+}
+
+func newDaemonSetFromData(daemonsetData []byte, pathToDaemonSetFile string) (*DaemonSet, error) {
+	newDaemonSet := &DaemonSet{DaemonSet: new(appsV1.DaemonSet)}
+	if err := decodeWorkload(daemonsetData, newDaemonSet.DaemonSet); err != nil {
+		return nil, err
+	}
+	newDaemonSet.podTemplateWorkload = newPodTemplateWorkload(
+		daemonsetData,
+		pathToDaemonSetFile,
+		newDaemonSet.DaemonSet,
+		&newDaemonSet.ObjectMeta,
+		&newDaemonSet.Spec.Template,
+		yamlPath{"spec", "template"},
+	)
+	return newDaemonSet, nil
+}
@@ -55,15 +55,38 @@ func (e ErrKeyNotFoundInObject) Error() string {
 	return fmt.Sprintf("key '%s' not found in object %v", e.Key, e.Object)
 }
 
-type ErrImageNotSpecified struct {}
+type ErrImageNotSpecified struct {
+	CandidateImages []string
+}
 
 func (e ErrImageNotSpecified) Error() string {
-	return fmt.Sprintf("more than one container in a pod and image name is not specified")
+	return "containers in a pod run more than one image and no image name or container selector is given, candidates are: " +
+		strings.Join(e.CandidateImages, ", ")
 }
 
-type ErrSuppliedImageNameNotInConfigFile struct {}
+type ErrSuppliedImageNameNotInConfigFile struct {
+	Selector        string
+	CandidateImages []string
+}
 
 func (e ErrSuppliedImageNameNotInConfigFile) Error() string {
-	return fmt.Sprintf("the supplied image name is not in the config file")
+	return fmt.Sprintf("no container selected by %s, candidates are: ", e.Selector) +
+		strings.Join(e.CandidateImages, ", ")
+}
+
+type ErrInvalidContainerSelector struct {
+	Reasons []string
 }
 
+func (e ErrInvalidContainerSelector) Error() string {
+	return "invalid container selector: " + strings.Join(e.Reasons, ", ")
+}
+
+type ErrInvalidImageReference struct {
+	Image   string
+	Reasons []string
+}
+
+func (e ErrInvalidImageReference) Error() string {
+	return fmt.Sprintf("invalid image reference '%s': ", e.Image) + strings.Join(e.Reasons, ", ")
+}
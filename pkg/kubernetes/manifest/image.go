@@ -0,0 +1,144 @@
+package manifest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	defaultRegistry        = "docker.io"
+	defaultRepositoryOwner = "library"
+)
+
+var (
+	imagePathComponentRegexp = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*$`)
+	imageTagRegexp           = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	imageDigestRegexp        = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,}$`)
+)
+
+/*
+ImageReference is a parsed OCI image reference of the form
+[registry[:port]/]path[:tag][@digest]
+*/
+type ImageReference struct {
+	Registry string
+	Path     string
+	Tag      string
+	Digest   string
+}
+
+/*
+ParseImageReference parses the given image into its registry, path, tag and digest.
+*/
+func ParseImageReference(image string) (*ImageReference, error) {
+	if image == "" {
+		return nil, ErrInvalidImageReference{Image: image, Reasons: []string{
+			"image is blank",
+		}}
+	}
+	imageReference := new(ImageReference)
+	remainder := image
+
+	// digest follows the last '@'
+	if i := strings.LastIndexByte(remainder, '@'); i != -1 {
+		imageReference.Digest = remainder[i+1:]
+		remainder = remainder[:i]
+		if !imageDigestRegexp.MatchString(imageReference.Digest) {
+			return nil, ErrInvalidImageReference{Image: image, Reasons: []string{
+				fmt.Sprintf("invalid digest '%s'", imageReference.Digest),
+			}}
+		}
+	}
+
+	// tag follows a ':' after the last '/' so that registry ports are not mistaken for tags
+	if i := strings.LastIndexByte(remainder, ':'); i > strings.LastIndexByte(remainder, '/') {
+		imageReference.Tag = remainder[i+1:]
+		remainder = remainder[:i]
+		if !imageTagRegexp.MatchString(imageReference.Tag) {
+			return nil, ErrInvalidImageReference{Image: image, Reasons: []string{
+				fmt.Sprintf("invalid tag '%s'", imageReference.Tag),
+			}}
+		}
+	}
+
+	// the first component is a registry if it looks like a host
+	if i := strings.IndexByte(remainder, '/'); i != -1 {
+		if host := remainder[:i]; strings.ContainsAny(host, ".:") || host == "localhost" {
+			imageReference.Registry = host
+			remainder = remainder[i+1:]
+		}
+	}
+
+	imageReference.Path = remainder
+	for _, pathComponent := range strings.Split(imageReference.Path, "/") {
+		if !imagePathComponentRegexp.MatchString(pathComponent) {
+			return nil, ErrInvalidImageReference{Image: image, Reasons: []string{
+				fmt.Sprintf("invalid path component '%s'", pathComponent),
+			}}
+		}
+	}
+
+	return imageReference, nil
+}
+
+/*
+Repository returns the registry and path of the image as it was given, without tag or digest.
+*/
+func (i *ImageReference) Repository() string {
+	if i.Registry == "" {
+		return i.Path
+	}
+	return i.Registry + "/" + i.Path
+}
+
+/*
+NormalisedRepository returns the repository with the default docker hub registry and
+library owner filled in so that e.g. 'nginx' and 'docker.io/library/nginx' are equal.
+*/
+func (i *ImageReference) NormalisedRepository() string {
+	registry := i.Registry
+	if registry == "" || registry == "index.docker.io" {
+		registry = defaultRegistry
+	}
+	path := i.Path
+	if registry == defaultRegistry && !strings.Contains(path, "/") {
+		path = defaultRepositoryOwner + "/" + path
+	}
+	return registry + "/" + path
+}
+
+/*
+SameRepository returns true if both images refer to the same repository.
+*/
+func (i *ImageReference) SameRepository(other *ImageReference) bool {
+	return i.NormalisedRepository() == other.NormalisedRepository()
+}
+
+/*
+WithTag returns a copy of the image reference with the given tag. The digest is
+removed since it would otherwise take precedence over the new tag.
+*/
+func (i *ImageReference) WithTag(tag string) (*ImageReference, error) {
+	if !imageTagRegexp.MatchString(tag) {
+		return nil, ErrInvalidImageReference{Image: i.String(), Reasons: []string{
+			fmt.Sprintf("invalid tag '%s'", tag),
+		}}
+	}
+	return &ImageReference{
+		Registry: i.Registry,
+		Path:     i.Path,
+		Tag:      tag,
+	}, nil
+}
+
+func (i *ImageReference) String() string {
+	image := i.Repository()
+	if i.Tag != "" {
+		image += ":" + i.Tag
+	}
+	if i.Digest != "" {
+		image += "@" + i.Digest
+	}
+	return image
+}
@@ -0,0 +1,161 @@
+package manifest
+
+import "testing"
+
+func TestParseImageReference(t *testing.T) {
+	digest := "sha256:" + "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	tests := []struct {
+		name  string
+		image string
+		want  ImageReference
+	}{
+		{
+			name:  "no tag",
+			image: "nginx",
+			want:  ImageReference{Path: "nginx"},
+		},
+		{
+			name:  "tag",
+			image: "nginx:1.17",
+			want:  ImageReference{Path: "nginx", Tag: "1.17"},
+		},
+		{
+			name:  "owner and tag",
+			image: "foo/api:v1.0.0",
+			want:  ImageReference{Path: "foo/api", Tag: "v1.0.0"},
+		},
+		{
+			name:  "registry",
+			image: "gcr.io/foo/api:latest",
+			want:  ImageReference{Registry: "gcr.io", Path: "foo/api", Tag: "latest"},
+		},
+		{
+			name:  "registry with port and no tag",
+			image: "registry:5000/foo/api",
+			want:  ImageReference{Registry: "registry:5000", Path: "foo/api"},
+		},
+		{
+			name:  "registry with port and tag",
+			image: "registry:5000/foo/api:1.0.0",
+			want:  ImageReference{Registry: "registry:5000", Path: "foo/api", Tag: "1.0.0"},
+		},
+		{
+			name:  "localhost registry",
+			image: "localhost/api:dev",
+			want:  ImageReference{Registry: "localhost", Path: "api", Tag: "dev"},
+		},
+		{
+			name:  "digest",
+			image: "foo/api@" + digest,
+			want:  ImageReference{Path: "foo/api", Digest: digest},
+		},
+		{
+			name:  "tag and digest",
+			image: "registry:5000/foo/api:1.0.0@" + digest,
+			want:  ImageReference{Registry: "registry:5000", Path: "foo/api", Tag: "1.0.0", Digest: digest},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			imageReference, err := ParseImageReference(test.image)
+			if err != nil {
+				t.Fatalf("ParseImageReference(%q) returned error: %s", test.image, err)
+			}
+			if *imageReference != test.want {
+				t.Errorf("ParseImageReference(%q) = %+v, want %+v", test.image, *imageReference, test.want)
+			}
+			if imageReference.String() != test.image {
+				t.Errorf("String() = %q, want %q", imageReference.String(), test.image)
+			}
+		})
+	}
+}
+
+func TestParseImageReferenceInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		image string
+	}{
+		{name: "blank", image: ""},
+		{name: "upper case path", image: "Foo/api:1.0.0"},
+		{name: "empty tag", image: "foo/api:"},
+		{name: "invalid tag", image: "foo/api:-1"},
+		{name: "short digest", image: "foo/api@sha256:abc"},
+		{name: "empty path component", image: "foo//api"},
+		{name: "registry only", image: "registry:5000/"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := ParseImageReference(test.image); err == nil {
+				t.Errorf("ParseImageReference(%q) returned no error", test.image)
+			} else if _, isInvalid := err.(ErrInvalidImageReference); !isInvalid {
+				t.Errorf("ParseImageReference(%q) returned %T, want ErrInvalidImageReference", test.image, err)
+			}
+		})
+	}
+}
+
+func TestSameRepository(t *testing.T) {
+	tests := []struct {
+		image string
+		other string
+		want  bool
+	}{
+		{image: "nginx", other: "docker.io/library/nginx:1.17", want: true},
+		{image: "index.docker.io/foo/api", other: "foo/api:latest", want: true},
+		{image: "registry:5000/foo/api:1.0.0", other: "registry:5000/foo/api:2.0.0", want: true},
+		{image: "registry:5000/foo/api", other: "foo/api", want: false},
+		{image: "foo/api", other: "foo/api-migrations", want: false},
+	}
+	for _, test := range tests {
+		t.Run(test.image+" "+test.other, func(t *testing.T) {
+			image, err := ParseImageReference(test.image)
+			if err != nil {
+				t.Fatal(err)
+			}
+			other, err := ParseImageReference(test.other)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := image.SameRepository(other); got != test.want {
+				t.Errorf("SameRepository() = %t, want %t", got, test.want)
+			}
+		})
+	}
+}
+
+func TestWithTag(t *testing.T) {
+	digest := "sha256:" + "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	tests := []struct {
+		image string
+		tag   string
+		want  string
+	}{
+		{image: "foo/api", tag: "1.0.0", want: "foo/api:1.0.0"},
+		{image: "registry:5000/foo/api:1.0.0", tag: "2.0.0", want: "registry:5000/foo/api:2.0.0"},
+		{image: "foo/api:1.0.0@" + digest, tag: "2.0.0", want: "foo/api:2.0.0"},
+	}
+	for _, test := range tests {
+		t.Run(test.image, func(t *testing.T) {
+			image, err := ParseImageReference(test.image)
+			if err != nil {
+				t.Fatal(err)
+			}
+			updatedImage, err := image.WithTag(test.tag)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if updatedImage.String() != test.want {
+				t.Errorf("WithTag(%q) = %q, want %q", test.tag, updatedImage.String(), test.want)
+			}
+		})
+	}
+
+	image, err := ParseImageReference("foo/api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := image.WithTag("not a tag"); err == nil {
+		t.Error("WithTag with an invalid tag returned no error")
+	}
+}
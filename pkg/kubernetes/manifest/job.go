@@ -0,0 +1,29 @@
+package manifest
+
+import (
+	batchV1 "k8s.io/api/batch/v1"
+)
+
+/*
+Job is a convenience wrapper around the manifest object type that represents a job
+*/
+type Job struct {
+	*batchV1.Job
+	podTemplateWorkload
+}
+
+func newJobFromData(jobData []byte, pathToJobFile string) (*Job, error) {
+	newJob := &Job{Job: new(batchV1.Job)}
+	if err := decodeWorkload(jobData, newJob.Job); err != nil {
+		return nil, err
+	}
+	newJob.podTemplateWorkload = newPodTemplateWorkload(
+		jobData,
+		pathToJobFile,
+		newJob.Job,
+		&newJob.ObjectMeta,
+		&newJob.Spec.Template,
+		yamlPath{"spec", "template"},
+	)
+	return newJob, nil
+}
@@ -0,0 +1,168 @@
+package manifest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sigs.k8s.io/yaml"
+	"strings"
+)
+
+// kustomizationFileNames are the file names kustomize looks for in a kustomization directory
+var kustomizationFileNames = []string{"kustomization.yaml", "kustomization.yml"}
+
+/*
+Kustomization is a kustomization.yaml file whose images transformer entries are updated
+by kustomize instead of the images in the workload, e.g. so that a base manifest shared
+by overlays is left untouched
+*/
+type Kustomization struct {
+	yamlObject
+	Images []KustomizationImage
+}
+
+/*
+KustomizationImage is an entry of the images field of a kustomization. The image with
+Name is replaced by NewName, NewTag and Digest when the kustomization is built.
+*/
+type KustomizationImage struct {
+	Name    string `json:"name"`
+	NewName string `json:"newName,omitempty"`
+	NewTag  string `json:"newTag,omitempty"`
+	Digest  string `json:"digest,omitempty"`
+}
+
+/*
+NewKustomizationFromFile reads the kustomization at the given path, which is either the
+kustomization file or the directory containing it
+*/
+func NewKustomizationFromFile(pathToKustomization string) (*Kustomization, error) {
+	if fileInfo, err := os.Stat(pathToKustomization); err == nil && fileInfo.IsDir() {
+		pathToKustomizationFile := ""
+		for _, fileName := range kustomizationFileNames {
+			if _, err := os.Stat(filepath.Join(pathToKustomization, fileName)); err == nil {
+				pathToKustomizationFile = filepath.Join(pathToKustomization, fileName)
+				break
+			}
+		}
+		if pathToKustomizationFile == "" {
+			return nil, ErrInvalidFilePath{Reasons: []string{
+				fmt.Sprintf("no kustomization file in '%s'", pathToKustomization),
+			}}
+		}
+		pathToKustomization = pathToKustomizationFile
+	}
+	pathToKustomization, err := validateFilePath(pathToKustomization)
+	if err != nil {
+		return nil, err
+	}
+
+	kustomizationData, err := ioutil.ReadFile(pathToKustomization)
+	if err != nil {
+		return nil, ErrUnexpected{Reasons: []string{
+			"reading kustomization file",
+			err.Error(),
+		}}
+	}
+	var kustomization struct {
+		Images []KustomizationImage `json:"images"`
+	}
+	if err := yaml.Unmarshal(kustomizationData, &kustomization); err != nil {
+		return nil, ErrManifestInvalid{Reasons: []string{
+			"decoding kustomization file",
+			err.Error(),
+		}}
+	}
+
+	return &Kustomization{
+		yamlObject: yamlObject{
+			PathToFile: pathToKustomization,
+			data:       kustomizationData,
+		},
+		Images: kustomization.Images,
+	}, nil
+}
+
+/*
+Dir returns the directory of the kustomization which is built by kustomize
+*/
+func (k *Kustomization) Dir() string {
+	return filepath.Dir(k.PathToFile)
+}
+
+/*
+UpdateImage updates the images entry for the image with the given name. If no image name
+is given the kustomization must have only one images entry. A tag starting with 'sha256:'
+is set as the digest of the image, any other tag is set as its new tag. The new name is
+only set if one is given.
+*/
+func (k *Kustomization) UpdateImage(imageName, newName, tag string) (KustomizationImage, error) {
+	entry, err := k.findImage(imageName)
+	if err != nil {
+		return KustomizationImage{}, err
+	}
+
+	image := &k.Images[entry]
+	if newName != "" {
+		image.NewName = newName
+		k.recordEdit(yamlPath{"images", entry, "newName"}, newName)
+	}
+	if strings.HasPrefix(tag, "sha256:") {
+		image.Digest = tag
+		k.recordEdit(yamlPath{"images", entry, "digest"}, tag)
+		return *image, nil
+	}
+	image.NewTag = tag
+	k.recordEdit(yamlPath{"images", entry, "newTag"}, tag)
+	if image.Digest != "" {
+		// a digest takes precedence over the tag so it is cleared
+		image.Digest = ""
+		k.recordRemoval(yamlPath{"images", entry, "digest"})
+	}
+	return *image, nil
+}
+
+// findImage returns the index of the images entry for the image with the given name
+func (k *Kustomization) findImage(imageName string) (int, error) {
+	var imageNames []string
+	for _, image := range k.Images {
+		imageNames = append(imageNames, image.Name)
+	}
+
+	if imageName == "" {
+		if len(k.Images) != 1 {
+			return 0, ErrManifestInvalid{Reasons: []string{
+				fmt.Sprintf("kustomization '%s' does not have exactly one images entry and image name is not specified", k.PathToFile),
+				fmt.Sprintf("images found: [%s]", strings.Join(imageNames, ", ")),
+			}}
+		}
+		return 0, nil
+	}
+
+	image, err := ParseImageReference(imageName)
+	if err != nil {
+		return 0, err
+	}
+	for i, entry := range k.Images {
+		entryImage, err := ParseImageReference(entry.Name)
+		if err != nil {
+			return 0, err
+		}
+		if entryImage.SameRepository(image) {
+			return i, nil
+		}
+	}
+	return 0, ErrManifestInvalid{Reasons: []string{
+		fmt.Sprintf("no images entry for '%s' in kustomization '%s'", imageName, k.PathToFile),
+		fmt.Sprintf("images found: [%s]", strings.Join(imageNames, ", ")),
+	}}
+}
+
+func (k *Kustomization) String() string {
+	kustomizationData, err := k.toYAML()
+	if err != nil {
+		return err.Error()
+	}
+	return string(kustomizationData)
+}
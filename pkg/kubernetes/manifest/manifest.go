@@ -0,0 +1,252 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sigs.k8s.io/yaml"
+	"strings"
+)
+
+// documentSeparator matches the line which separates yaml documents in a stream
+var documentSeparator = regexp.MustCompile(`^---(\s.*)?$`)
+
+/*
+Manifest is a manifest file which may contain more than one yaml document, e.g. a
+Service and the Deployment which it exposes. One of the documents is the target
+Object which is updated by kubernite. All other documents are left untouched.
+*/
+type Manifest struct {
+	PathToFile string
+	Documents  []*Document
+	Object     Object
+
+	// Workload is the target object if it is a workload, otherwise nil
+	Workload Workload
+
+	// objectDocument is the index of the target object in Documents
+	objectDocument int
+}
+
+/*
+Document is a single yaml document in a manifest file
+*/
+type Document struct {
+	// Separator is the '---' line preceding the document, blank for the first document
+	Separator string
+	Data      []byte
+	Kind      string
+	Name      string
+	Namespace string
+}
+
+/*
+NewManifestFromFile reads every document in the manifest file at the given path and
+finds the object with the given kind and name. If no kind is given only workloads are
+considered. The kind and name may be left blank if the file contains only one workload.
+*/
+func NewManifestFromFile(pathToManifestFile, objectKind, objectName string) (*Manifest, error) {
+	pathToManifestFile, err := validateFilePath(pathToManifestFile)
+	if err != nil {
+		return nil, err
+	}
+	manifestData, err := ioutil.ReadFile(pathToManifestFile)
+	if err != nil {
+		return nil, ErrUnexpected{Reasons: []string{
+			"reading manifest file",
+			err.Error(),
+		}}
+	}
+	return NewManifestFromData(manifestData, pathToManifestFile, objectKind, objectName)
+}
+
+/*
+NewManifestFromData finds the object with the given kind and name in the given yaml
+documents, e.g. the output of kustomize build. The path is the file the documents are
+written to.
+*/
+func NewManifestFromData(manifestData []byte, pathToManifestFile, objectKind, objectName string) (*Manifest, error) {
+	var err error
+	newManifest := &Manifest{
+		PathToFile:     pathToManifestFile,
+		objectDocument: -1,
+	}
+	if newManifest.Documents, err = splitDocuments(manifestData); err != nil {
+		return nil, err
+	}
+
+	// find the target object
+	var objects []string
+	for i, document := range newManifest.Documents {
+		if document.Kind == "" {
+			continue
+		}
+		objects = append(objects, document.Kind+"/"+document.Name)
+		if objectKind == "" && !IsWorkloadKind(document.Kind) {
+			continue
+		}
+		if objectKind != "" && document.Kind != objectKind {
+			continue
+		}
+		if objectName != "" && document.Name != objectName {
+			continue
+		}
+		if newManifest.objectDocument != -1 {
+			return nil, ErrManifestInvalid{Reasons: []string{
+				fmt.Sprintf("more than one workload in '%s' and kind and name are not specified", pathToManifestFile),
+				fmt.Sprintf("objects found: [%s]", strings.Join(objects, ", ")),
+			}}
+		}
+		newManifest.objectDocument = i
+	}
+	if newManifest.objectDocument == -1 {
+		return nil, ErrManifestInvalid{Reasons: []string{
+			fmt.Sprintf("object '%s/%s' not found in '%s'", objectKind, objectName, pathToManifestFile),
+			fmt.Sprintf("objects found: [%s]", strings.Join(objects, ", ")),
+		}}
+	}
+
+	// decode workloads into their typed objects and all other kinds as unstructured objects
+	objectDocument := newManifest.Documents[newManifest.objectDocument]
+	if newWorkload, isWorkload := workloadDecoders[objectDocument.Kind]; isWorkload {
+		if newManifest.Workload, err = newWorkload(objectDocument.Data, pathToManifestFile); err != nil {
+			return nil, err
+		}
+		newManifest.Object = newManifest.Workload
+	} else {
+		if newManifest.Object, err = newUnstructuredFromData(objectDocument.Data, pathToManifestFile); err != nil {
+			return nil, err
+		}
+	}
+
+	return newManifest, nil
+}
+
+/*
+WriteToYAML writes all of the documents in the manifest file to disk at it's original filepath
+*/
+func (m *Manifest) WriteToYAML() error {
+	return m.WriteToYAMLAtPath(m.PathToFile)
+}
+
+/*
+WriteToYAMLAtPath writes all of the documents in the manifest file to disk at given file
+path in their original order
+*/
+func (m *Manifest) WriteToYAMLAtPath(pathToWriteManifestFile string) error {
+	manifestData, err := m.YAML()
+	if err != nil {
+		return err
+	}
+	return writeYAMLFile(pathToWriteManifestFile, manifestData)
+}
+
+/*
+YAML returns all of the documents in the manifest file with the updated object
+*/
+func (m *Manifest) YAML() ([]byte, error) {
+	objectData, err := m.Object.toYAML()
+	if err != nil {
+		return nil, err
+	}
+
+	var manifestData bytes.Buffer
+	for i, document := range m.Documents {
+		manifestData.WriteString(document.Separator)
+		if i == m.objectDocument {
+			manifestData.Write(objectData)
+		} else {
+			manifestData.Write(document.Data)
+		}
+	}
+	return manifestData.Bytes(), nil
+}
+
+func (m *Manifest) String() string {
+	manifestData, err := m.YAML()
+	if err != nil {
+		return err.Error()
+	}
+	return string(manifestData)
+}
+
+// splitDocuments splits a yaml stream into its documents keeping all of the
+// original text so that documents can be written back unchanged
+func splitDocuments(manifestData []byte) ([]*Document, error) {
+	documents := []*Document{new(Document)}
+	for _, line := range bytes.SplitAfter(manifestData, []byte("\n")) {
+		if documentSeparator.Match(bytes.TrimRight(line, "\r\n")) {
+			documents = append(documents, &Document{Separator: string(line)})
+			continue
+		}
+		documents[len(documents)-1].Data = append(documents[len(documents)-1].Data, line...)
+	}
+
+	for i, document := range documents {
+		var objectMeta struct {
+			Kind     string `json:"kind"`
+			Metadata struct {
+				Name      string `json:"name"`
+				Namespace string `json:"namespace"`
+			} `json:"metadata"`
+		}
+		if err := yaml.Unmarshal(document.Data, &objectMeta); err != nil {
+			return nil, ErrManifestInvalid{Reasons: []string{
+				fmt.Sprintf("decoding document %d", i),
+				err.Error(),
+			}}
+		}
+		document.Kind = objectMeta.Kind
+		document.Name = objectMeta.Metadata.Name
+		document.Namespace = objectMeta.Metadata.Namespace
+	}
+
+	return documents, nil
+}
+
+// validateFilePath returns the absolute path of the given file if it exists
+func validateFilePath(pathToFile string) (string, error) {
+	pathToFile, err := filepath.Abs(pathToFile)
+	if err != nil {
+		return "", ErrInvalidFilePath{Reasons: []string{
+			"could not convert to absolute path",
+			err.Error(),
+		}}
+	}
+	fileInfo, err := os.Stat(pathToFile)
+	if err != nil {
+		return "", ErrInvalidFilePath{Reasons: []string{
+			"could not get file info at path",
+			err.Error(),
+		}}
+	}
+	if fileInfo.IsDir() {
+		return "", ErrInvalidFilePath{Reasons: []string{
+			fmt.Sprintf("'%s' is a directory", pathToFile),
+		}}
+	}
+	return pathToFile, nil
+}
+
+// writeYAMLFile writes the given yaml data to the given .yaml or .yml file path
+func writeYAMLFile(pathToFile string, yamlData []byte) error {
+	// confirm that file path has correct extension
+	if !(strings.HasSuffix(pathToFile, ".yaml") || strings.HasSuffix(pathToFile, ".yml")) {
+		return ErrInvalidFilePath{Reasons: []string{
+			fmt.Sprintf("'%s' does not end in .yaml or .yml", pathToFile),
+		}}
+	}
+
+	// write to file
+	if err := ioutil.WriteFile(pathToFile, yamlData, 0644); err != nil {
+		return ErrUnexpected{Reasons: []string{
+			"writing to file",
+			err.Error(),
+		}}
+	}
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package manifest
+
+/*
+yamlObject is a manifest object decoded from yaml. Changes to the object are recorded
+as edits to the yaml so that only the edited fields change when the object is written.
+*/
+type yamlObject struct {
+	PathToFile string
+
+	// data is the yaml the object was decoded from
+	data  []byte
+	edits []yamlEdit
+}
+
+func (o *yamlObject) recordEdit(path yamlPath, value string) {
+	o.recordScalarEdit(path, yamlScalar{Text: value, Value: value, Tag: strTag})
+}
+
+// recordScalarEdit records an edit setting the field at path to the given scalar
+func (o *yamlObject) recordScalarEdit(path yamlPath, scalar yamlScalar) {
+	o.edits = append(o.edits, yamlEdit{
+		Path:  path,
+		Value: scalar.Text,
+		Tag:   scalar.Tag,
+	})
+}
+
+// recordRemoval records an edit removing the field at path
+func (o *yamlObject) recordRemoval(path yamlPath) {
+	o.edits = append(o.edits, yamlEdit{
+		Path:   path,
+		Remove: true,
+	})
+}
+
+/*
+WriteToYAML writes the manifest file to disk at it's original filepath
+*/
+func (o *yamlObject) WriteToYAML() error {
+	return o.WriteToYAMLAtPath(o.PathToFile)
+}
+
+/*
+WriteToYAMLAtPath writes the manifest file to disk at given file path
+*/
+func (o *yamlObject) WriteToYAMLAtPath(pathToWriteManifestFile string) error {
+	yamlData, err := o.toYAML()
+	if err != nil {
+		return err
+	}
+	return writeYAMLFile(pathToWriteManifestFile, yamlData)
+}
+
+/*
+YAML returns the yaml the object was decoded from with the recorded edits applied
+*/
+func (o *yamlObject) YAML() ([]byte, error) {
+	return o.toYAML()
+}
+
+// toYAML returns the yaml the object was decoded from with the recorded edits applied
+func (o *yamlObject) toYAML() ([]byte, error) {
+	return applyYAMLEdits(o.data, o.edits)
+}
+
+/*
+ApplyEdits makes the changes recorded for the object to the given unstructured object, e.g.
+the live object in the cluster, so that only the fields changed by kubernite are changed.
+Sequence elements selected by key, such as containers by name, are selected by the same
+key in the given object and must exist in it.
+*/
+func (o *yamlObject) ApplyEdits(object map[string]interface{}) error {
+	for _, edit := range o.edits {
+		if edit.Remove {
+			if err := removePath(object, edit.Path); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := setPath(object, edit.Path, edit.typedValue()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removePath removes the field at the given yaml path from the given unstructured object, if it exists
+func removePath(object map[string]interface{}, path yamlPath) error {
+	key, isKey := path[len(path)-1].(string)
+	if !isKey {
+		return ErrInvalidAccessorPath{AccessorPath: path.String(), Object: path[:len(path)-1].String()}
+	}
+	// find the mapping holding the field without creating mappings which do not exist
+	var parent interface{} = object
+	for i, element := range path[:len(path)-1] {
+		switch element := element.(type) {
+		case string:
+			mapping, isMapping := parent.(map[string]interface{})
+			if !isMapping {
+				return ErrInvalidAccessorPath{AccessorPath: path.String(), Object: path[:i].String()}
+			}
+			if parent = mapping[element]; parent == nil {
+				return nil
+			}
+		case int:
+			sequence, isSequence := parent.([]interface{})
+			if !isSequence || element < 0 || element >= len(sequence) {
+				return ErrKeyNotFoundInObject{Key: path[:i+1].String(), Object: path[:i].String()}
+			}
+			parent = sequence[element]
+		case sequenceSelector:
+			sequence, isSequence := parent.([]interface{})
+			if !isSequence {
+				return ErrInvalidAccessorPath{AccessorPath: path.String(), Object: path[:i].String()}
+			}
+			index := selectIndex(sequence, element.Key, element.Value)
+			if index == -1 {
+				return ErrKeyNotFoundInObject{Key: path[:i+1].String(), Object: path[:i].String()}
+			}
+			parent = sequence[index]
+		default:
+			return ErrInvalidAccessorPath{AccessorPath: path.String(), Object: path[:i].String()}
+		}
+	}
+	mapping, isMapping := parent.(map[string]interface{})
+	if !isMapping {
+		return ErrInvalidAccessorPath{AccessorPath: path.String(), Object: path[:len(path)-1].String()}
+	}
+	delete(mapping, key)
+	return nil
+}
+
+// setPath sets the field at the given yaml path in the given unstructured object, creating mappings which do not exist
+func setPath(object map[string]interface{}, path yamlPath, value interface{}) error {
+	var current interface{} = object
+	for i, element := range path {
+		last := i == len(path)-1
+		switch key := element.(type) {
+		case string:
+			mapping, isMapping := current.(map[string]interface{})
+			if !isMapping {
+				return ErrInvalidAccessorPath{AccessorPath: path.String(), Object: path[:i].String()}
+			}
+			if last {
+				mapping[key] = value
+				return nil
+			}
+			next, found := mapping[key]
+			if !found || next == nil {
+				next = make(map[string]interface{})
+				mapping[key] = next
+			}
+			current = next
+
+		case int:
+			sequence, isSequence := current.([]interface{})
+			if !isSequence {
+				return ErrInvalidAccessorPath{AccessorPath: path.String(), Object: path[:i].String()}
+			}
+			if key < 0 || key >= len(sequence) {
+				return ErrKeyNotFoundInObject{Key: path[:i+1].String(), Object: path[:i].String()}
+			}
+			if last {
+				sequence[key] = value
+				return nil
+			}
+			current = sequence[key]
+
+		case sequenceSelector:
+			sequence, isSequence := current.([]interface{})
+			if !isSequence {
+				return ErrInvalidAccessorPath{AccessorPath: path.String(), Object: path[:i].String()}
+			}
+			index := selectIndex(sequence, key.Key, key.Value)
+			if index == -1 {
+				return ErrKeyNotFoundInObject{Key: path[:i+1].String(), Object: path[:i].String()}
+			}
+			if last {
+				sequence[index] = value
+				return nil
+			}
+			current = sequence[index]
+
+		default:
+			return ErrInvalidAccessorPath{AccessorPath: path.String(), Object: path[:i].String()}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package manifest
+
+import (
+	appsV1 "k8s.io/api/apps/v1"
+)
+
+/*
+ReplicaSet is a convenience wrapper around the manifest object type that represents a replicaset
+*/
+type ReplicaSet struct {
+	*appsV1.ReplicaSet
+	podTemplateWorkload
+}
+
+func newReplicaSetFromData(replicasetData []byte, pathToReplicaSetFile string) (*ReplicaSet, error) {
+	newReplicaSet := &ReplicaSet{ReplicaSet: new(appsV1.ReplicaSet)}
+	if err := decodeWorkload(replicasetData, newReplicaSet.ReplicaSet); err != nil {
+		return nil, err
+	}
+	newReplicaSet.podTemplateWorkload = newPodTemplateWorkload(
+		replicasetData,
+		pathToReplicaSetFile,
+		newReplicaSet.ReplicaSet,
+		&newReplicaSet.ObjectMeta,
+		&newReplicaSet.Spec.Template,
+		yamlPath{"spec", "template"},
+	)
+	return newReplicaSet, nil
+}
@@ -0,0 +1,29 @@
+package manifest
+
+import (
+	appsV1 "k8s.io/api/apps/v1"
+)
+
+/*
+StatefulSet is a convenience wrapper around the manifest object type that represents a statefulset
+*/
+type StatefulSet struct {
+	*appsV1.StatefulSet
+	podTemplateWorkload
+}
+
+func newStatefulSetFromData(statefulsetData []byte, pathToStatefulSetFile string) (*StatefulSet, error) {
+	newStatefulSet := &StatefulSet{StatefulSet: new(appsV1.StatefulSet)}
+	if err := decodeWorkload(statefulsetData, newStatefulSet.StatefulSet); err != nil {
+		return nil, err
+	}
+	newStatefulSet.podTemplateWorkload = newPodTemplateWorkload(
+		statefulsetData,
+		pathToStatefulSetFile,
+		newStatefulSet.StatefulSet,
+		&newStatefulSet.ObjectMeta,
+		&newStatefulSet.Spec.Template,
+		yamlPath{"spec", "template"},
+	)
+	return newStatefulSet, nil
+}
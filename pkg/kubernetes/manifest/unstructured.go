@@ -0,0 +1,70 @@
+package manifest
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/yaml"
+)
+
+/*
+Unstructured is a manifest object of a kind which kubernite does not model, such as a
+custom resource. Its fields can only be updated by accessor path.
+*/
+type Unstructured struct {
+	*unstructured.Unstructured
+	yamlObject
+}
+
+func newUnstructuredFromData(data []byte, pathToFile string) (*Unstructured, error) {
+	jsonData, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, ErrUnexpected{Reasons: []string{
+			"converting yaml to json",
+			err.Error(),
+		}}
+	}
+	object, err := runtime.Decode(unstructured.UnstructuredJSONScheme, jsonData)
+	if err != nil {
+		return nil, ErrUnexpected{Reasons: []string{
+			"decoding unstructured object",
+			err.Error(),
+		}}
+	}
+	unstructuredObject, isUnstructured := object.(*unstructured.Unstructured)
+	if !isUnstructured {
+		return nil, ErrManifestInvalid{Reasons: []string{
+			"document is a list of objects",
+		}}
+	}
+	return &Unstructured{
+		Unstructured: unstructuredObject,
+		yamlObject: yamlObject{
+			PathToFile: pathToFile,
+			data:       data,
+		},
+	}, nil
+}
+
+func (u *Unstructured) UpdateAnnotations(key, value string) error {
+	// annotation values are always strings
+	path, err := setField(u.Object, u.GetName(), `metadata.annotations["`+key+`"]`, value)
+	if err != nil {
+		return err
+	}
+	u.recordEdit(path, value)
+	return nil
+}
+
+/*
+SetField sets the field at the given accessor path, e.g. spec.image.tag, to the given value.
+The value is resolved as a yaml scalar, e.g. 3 is set as an integer.
+*/
+func (u *Unstructured) SetField(accessorPath, value string) error {
+	scalar := parseScalar(value)
+	path, err := setField(u.Object, u.GetName(), accessorPath, scalar.Value)
+	if err != nil {
+		return err
+	}
+	u.recordScalarEdit(path, scalar)
+	return nil
+}
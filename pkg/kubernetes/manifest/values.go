@@ -0,0 +1,87 @@
+package manifest
+
+import (
+	"io/ioutil"
+	"sigs.k8s.io/yaml"
+)
+
+/*
+Values is a helm chart values file, e.g. values.yaml, whose fields are updated by
+accessor path so that the chart in git records the deployed image tag
+*/
+type Values struct {
+	yamlObject
+	values map[string]interface{}
+}
+
+/*
+NewValuesFromFile reads the helm values file at the given path
+*/
+func NewValuesFromFile(pathToValuesFile string) (*Values, error) {
+	pathToValuesFile, err := validateFilePath(pathToValuesFile)
+	if err != nil {
+		return nil, err
+	}
+	valuesData, err := ioutil.ReadFile(pathToValuesFile)
+	if err != nil {
+		return nil, ErrUnexpected{Reasons: []string{
+			"reading values file",
+			err.Error(),
+		}}
+	}
+	var values map[string]interface{}
+	if err := yaml.Unmarshal(valuesData, &values); err != nil {
+		return nil, ErrManifestInvalid{Reasons: []string{
+			"decoding values file",
+			err.Error(),
+		}}
+	}
+	if values == nil {
+		return nil, ErrManifestInvalid{Reasons: []string{
+			"values file is empty",
+		}}
+	}
+
+	return &Values{
+		yamlObject: yamlObject{
+			PathToFile: pathToValuesFile,
+			data:       valuesData,
+		},
+		values: values,
+	}, nil
+}
+
+/*
+SetField sets the field at the given accessor path, e.g. replicaCount, to the given value.
+The value is resolved as a yaml scalar, e.g. 3 is set as an integer.
+*/
+func (v *Values) SetField(accessorPath, value string) error {
+	scalar := parseScalar(value)
+	path, err := setField(v.values, v.PathToFile, accessorPath, scalar.Value)
+	if err != nil {
+		return err
+	}
+	v.recordScalarEdit(path, scalar)
+	return nil
+}
+
+/*
+SetStringField sets the field at the given accessor path, e.g. image.tag, to the given
+value as a string, so that e.g. a tag such as 1.10 is not set as a number
+*/
+func (v *Values) SetStringField(accessorPath, value string) error {
+	path, err := setField(v.values, v.PathToFile, accessorPath, value)
+	if err != nil {
+		return err
+	}
+	v.recordEdit(path, value)
+	return nil
+}
+
+func (v *Values) String() string {
+	valuesData, err := v.toYAML()
+	if err != nil {
+		return err.Error()
+	}
+	return string(valuesData)
+}
@@ -0,0 +1,170 @@
+package manifest
+
+import (
+	"bytes"
+	"fmt"
+	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sYamlUtil "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+/*
+Object is a manifest object which kubernite can update
+*/
+type Object interface {
+	metaV1.Object
+	runtime.Object
+	UpdateAnnotations(key, value string) error
+	SetField(accessorPath, value string) error
+	ApplyEdits(object map[string]interface{}) error
+	WriteToYAML() error
+	WriteToYAMLAtPath(pathToWriteManifestFile string) error
+	YAML() ([]byte, error)
+	toYAML() ([]byte, error)
+}
+
+/*
+Workload is a manifest object which runs pods from a pod template, such as a Deployment
+or CronJob
+*/
+type Workload interface {
+	Object
+	PodTemplate() *coreV1.PodTemplateSpec
+	UpdatePodTemplateAnnotations(key, value string) error
+	UpdateImageTag(imageName, latestTag string) error
+	UpdateImageTags(imageUpdates []ImageUpdate) ([]ContainerUpdate, error)
+	RecordImages()
+}
+
+// workloadDecoders decode the yaml of each supported workload kind
+var workloadDecoders = map[string]func(data []byte, pathToFile string) (Workload, error){
+	"Deployment": func(data []byte, pathToFile string) (Workload, error) { return newDeploymentFromData(data, pathToFile) },
+	"StatefulSet": func(data []byte, pathToFile string) (Workload, error) {
+		return newStatefulSetFromData(data, pathToFile)
+	},
+	"DaemonSet":  func(data []byte, pathToFile string) (Workload, error) { return newDaemonSetFromData(data, pathToFile) },
+	"ReplicaSet": func(data []byte, pathToFile string) (Workload, error) { return newReplicaSetFromData(data, pathToFile) },
+	"Job":        func(data []byte, pathToFile string) (Workload, error) { return newJobFromData(data, pathToFile) },
+	"CronJob":    func(data []byte, pathToFile string) (Workload, error) { return newCronJobFromData(data, pathToFile) },
+}
+
+/*
+IsWorkloadKind returns true if the given kind is a supported workload kind
+*/
+func IsWorkloadKind(kind string) bool {
+	_, found := workloadDecoders[kind]
+	return found
+}
+
+/*
+podTemplateWorkload implements the updates common to all workloads
+*/
+type podTemplateWorkload struct {
+	yamlObject
+
+	object          runtime.Object
+	objectMeta      *metaV1.ObjectMeta
+	podTemplate     *coreV1.PodTemplateSpec
+	podTemplatePath yamlPath
+}
+
+func newPodTemplateWorkload(
+	data []byte,
+	pathToFile string,
+	object runtime.Object,
+	objectMeta *metaV1.ObjectMeta,
+	podTemplate *coreV1.PodTemplateSpec,
+	podTemplatePath yamlPath,
+) podTemplateWorkload {
+	return podTemplateWorkload{
+		yamlObject: yamlObject{
+			PathToFile: pathToFile,
+			data:       data,
+		},
+		object:          object,
+		objectMeta:      objectMeta,
+		podTemplate:     podTemplate,
+		podTemplatePath: podTemplatePath,
+	}
+}
+
+func (w *podTemplateWorkload) PodTemplate() *coreV1.PodTemplateSpec {
+	return w.podTemplate
+}
+
+func (w *podTemplateWorkload) UpdateAnnotations(key, value string) error {
+	if w.objectMeta.Annotations == nil {
+		w.objectMeta.Annotations = make(map[string]string)
+	}
+	w.objectMeta.Annotations[key] = value
+	w.recordEdit(yamlPath{"metadata", "annotations", key}, value)
+	return nil
+}
+
+/*
+SetField sets the field at the given accessor path, e.g.
+spec.template.spec.containers[name=api].image, to the given value. The value is resolved
+as a yaml scalar, e.g. 3 is set as an integer, unless the field is a string.
+*/
+func (w *podTemplateWorkload) SetField(accessorPath, value string) error {
+	scalar := parseScalar(value)
+	path, err := w.setTypedField(accessorPath, scalar.Value)
+	if _, isInvalid := err.(ErrManifestInvalid); isInvalid && scalar.Tag != strTag {
+		// the field is a string, e.g. the value of an environment variable
+		scalar = yamlScalar{Text: value, Value: value, Tag: strTag}
+		path, err = w.setTypedField(accessorPath, scalar.Value)
+	}
+	if err != nil {
+		return err
+	}
+	w.recordScalarEdit(path, scalar)
+	return nil
+}
+
+// setTypedField sets the field at the given accessor path in the typed workload object
+func (w *podTemplateWorkload) setTypedField(accessorPath string, value interface{}) (yamlPath, error) {
+	unstructuredObject, err := runtime.DefaultUnstructuredConverter.ToUnstructured(w.object)
+	if err != nil {
+		return nil, ErrUnexpected{Reasons: []string{
+			"converting workload to unstructured object",
+			err.Error(),
+		}}
+	}
+	path, err := setField(unstructuredObject, w.objectMeta.Name, accessorPath, value)
+	if err != nil {
+		return nil, err
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(unstructuredObject, w.object); err != nil {
+		return nil, ErrManifestInvalid{Reasons: []string{
+			fmt.Sprintf("setting '%s' to '%v'", accessorPath, value),
+			err.Error(),
+		}}
+	}
+	return path, nil
+}
+
+func (w *podTemplateWorkload) UpdatePodTemplateAnnotations(key, value string) error {
+	if w.podTemplate.Annotations == nil {
+		w.podTemplate.Annotations = make(map[string]string)
+	}
+	w.podTemplate.Annotations[key] = value
+	w.recordEdit(w.podTemplateField("metadata", "annotations", key), value)
+	return nil
+}
+
+// podTemplateField returns the path to the given field of the pod template
+func (w *podTemplateWorkload) podTemplateField(field ...interface{}) yamlPath {
+	return append(append(yamlPath{}, w.podTemplatePath...), field...)
+}
+
+// decodeWorkload decodes workload yaml into the given typed object
+func decodeWorkload(data []byte, object interface{}) error {
+	if err := k8sYamlUtil.NewYAMLOrJSONDecoder(bytes.NewReader(data), 512).Decode(object); err != nil {
+		return ErrUnexpected{Reasons: []string{
+			"decoding workload",
+			err.Error(),
+		}}
+	}
+	return nil
+}
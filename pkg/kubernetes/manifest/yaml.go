@@ -0,0 +1,687 @@
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"math"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+/*
+yamlPath is the path to a field in a yaml document. Each element is either a mapping
+key (string), a sequence index (int) or a sequence selector (sequenceSelector).
+*/
+type yamlPath []interface{}
+
+/*
+sequenceSelector selects the mapping in a sequence with Key set to Value, e.g. a container
+by its name, so that the same element is found in documents in which the sequence is
+ordered differently, such as the live object in the cluster
+*/
+type sequenceSelector struct {
+	Key   string
+	Value string
+}
+
+func (p yamlPath) String() string {
+	var path strings.Builder
+	for _, element := range p {
+		switch element := element.(type) {
+		case int:
+			path.WriteString(fmt.Sprintf("[%d]", element))
+		case sequenceSelector:
+			path.WriteString(fmt.Sprintf("[%s=%s]", element.Key, element.Value))
+		default:
+			if path.Len() > 0 {
+				path.WriteString(".")
+			}
+			path.WriteString(fmt.Sprint(element))
+		}
+	}
+	return path.String()
+}
+
+// strTag is the tag of a string scalar
+const strTag = "!!str"
+
+/*
+yamlEdit sets the scalar field at Path to Value, which is resolved as a scalar with the
+given tag, e.g. !!int for 3. If Remove is set the field at Path is removed instead.
+*/
+type yamlEdit struct {
+	Path   yamlPath
+	Value  string
+	Tag    string
+	Remove bool
+}
+
+// typedValue returns the value of the edit as the go type of its tag, as used in unstructured objects
+func (e yamlEdit) typedValue() interface{} {
+	if e.Tag == strTag {
+		return e.Value
+	}
+	return parseScalar(e.Value).Value
+}
+
+/*
+yamlScalar is a value resolved as a yaml scalar. Text is the value as written in yaml,
+Value is the value as the go type used in unstructured objects and Tag is its tag.
+*/
+type yamlScalar struct {
+	Text  string
+	Value interface{}
+	Tag   string
+}
+
+/*
+parseScalar resolves the given value as a yaml scalar, e.g. 3 as an integer or false as a
+boolean. Quoted values are strings without their quotes and values which are not a plain
+int, float, bool or null scalar are strings as given.
+*/
+func parseScalar(value string) yamlScalar {
+	stringScalar := yamlScalar{Text: value, Value: value, Tag: strTag}
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &document); err != nil || len(document.Content) != 1 {
+		return stringScalar
+	}
+	scalar := document.Content[0]
+	if scalar.Kind != yaml.ScalarNode || scalar.LineComment != "" || strings.TrimSpace(value) != value {
+		return stringScalar
+	}
+	if scalar.Style == yaml.DoubleQuotedStyle || scalar.Style == yaml.SingleQuotedStyle {
+		return yamlScalar{Text: scalar.Value, Value: scalar.Value, Tag: strTag}
+	}
+	if scalar.Style != 0 || scalar.Value != value {
+		return stringScalar
+	}
+
+	var decodedValue interface{}
+	if err := scalar.Decode(&decodedValue); err != nil {
+		return stringScalar
+	}
+	switch typedValue := decodedValue.(type) {
+	case int:
+		return yamlScalar{Text: value, Value: int64(typedValue), Tag: scalar.Tag}
+	case int64:
+		return yamlScalar{Text: value, Value: typedValue, Tag: scalar.Tag}
+	case float64:
+		// infinity and not a number can not be encoded as json
+		if math.IsInf(typedValue, 0) || math.IsNaN(typedValue) {
+			return stringScalar
+		}
+		return yamlScalar{Text: value, Value: typedValue, Tag: scalar.Tag}
+	case bool, nil:
+		return yamlScalar{Text: value, Value: typedValue, Tag: scalar.Tag}
+	default:
+		return stringScalar
+	}
+}
+
+/*
+applyYAMLEdits applies the given edits to the given yaml document. Only the text of the
+fields which are set or removed is rewritten so that the key order, formatting and
+comments of the document are kept. Fields which do not exist are added as the first keys
+of their parent mapping. If the document cannot be edited in place (e.g. a field is added
+to a flow mapping) the whole document is re-encoded, which keeps key order and comments
+but not formatting.
+*/
+func applyYAMLEdits(documentData []byte, edits []yamlEdit) ([]byte, error) {
+	if len(edits) == 0 {
+		return documentData, nil
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(documentData, &document); err != nil {
+		return nil, ErrUnexpected{Reasons: []string{
+			"decoding yaml document",
+			err.Error(),
+		}}
+	}
+	if document.Kind != yaml.DocumentNode || len(document.Content) == 0 {
+		return nil, ErrManifestInvalid{Reasons: []string{
+			"yaml document is empty",
+		}}
+	}
+
+	editor := newYAMLEditor(documentData)
+	for _, edit := range edits {
+		if edit.Remove {
+			if err := editor.remove(document.Content[0], edit.Path); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := editor.set(document.Content[0], edit.Path, edit.Value, edit.Tag); err != nil {
+			return nil, err
+		}
+	}
+
+	if patchedData, ok := editor.patch(); ok {
+		return patchedData, nil
+	}
+
+	// fall back to encoding the edited document
+	var encodedData bytes.Buffer
+	encoder := yaml.NewEncoder(&encodedData)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&document); err != nil {
+		return nil, ErrUnexpected{Reasons: []string{
+			"encoding yaml document",
+			err.Error(),
+		}}
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, ErrUnexpected{Reasons: []string{
+			"encoding yaml document",
+			err.Error(),
+		}}
+	}
+	return encodedData.Bytes(), nil
+}
+
+// originalScalar is the value and style of a scalar before it was edited
+type originalScalar struct {
+	Value string
+	Style yaml.Style
+}
+
+// yamlInsertion is a key added to a mapping which exists in the original document
+type yamlInsertion struct {
+	Mapping *yaml.Node
+	Key     *yaml.Node
+	Value   *yaml.Node
+}
+
+// yamlRemoval is a key removed from a mapping which exists in the original document
+type yamlRemoval struct {
+	Mapping *yaml.Node
+	Key     *yaml.Node
+	Value   *yaml.Node
+}
+
+// textEdit replaces the text between start and end with text
+type textEdit struct {
+	Start int
+	End   int
+	Text  string
+}
+
+/*
+yamlEditor edits a yaml node tree and keeps track of the changes made so that they can
+be patched into the original document text
+*/
+type yamlEditor struct {
+	data        []byte
+	lineOffsets []int
+	modified    map[*yaml.Node]originalScalar
+	insertions  []yamlInsertion
+	removals    []yamlRemoval
+
+	// reencode is set if a change cannot be patched into the original text
+	reencode bool
+}
+
+func newYAMLEditor(data []byte) *yamlEditor {
+	lineOffsets := []int{0}
+	for i, b := range data {
+		if b == '\n' {
+			lineOffsets = append(lineOffsets, i+1)
+		}
+	}
+	return &yamlEditor{
+		data:        data,
+		lineOffsets: lineOffsets,
+		modified:    make(map[*yaml.Node]originalScalar),
+	}
+}
+
+func (e *yamlEditor) set(node *yaml.Node, path yamlPath, value, tag string) error {
+	for i, element := range path {
+		switch key := element.(type) {
+		case string:
+			if isNull(node) {
+				// a null field such as 'annotations:' becomes a mapping
+				node.Kind, node.Tag, node.Value, node.Style = yaml.MappingNode, "!!map", "", 0
+				e.reencode = true
+			}
+			if node.Kind != yaml.MappingNode {
+				return ErrInvalidAccessorPath{AccessorPath: path.String(), Object: path[:i].String()}
+			}
+			valueNode := mappingValue(node, key)
+			if valueNode == nil {
+				return e.insert(node, path, i, value, tag)
+			}
+			node = valueNode
+
+		case int:
+			if node.Kind != yaml.SequenceNode {
+				return ErrInvalidAccessorPath{AccessorPath: path.String(), Object: path[:i].String()}
+			}
+			if key < 0 || key >= len(node.Content) {
+				return ErrKeyNotFoundInObject{Key: fmt.Sprintf("[%d]", key), Object: path[:i].String()}
+			}
+			node = node.Content[key]
+
+		case sequenceSelector:
+			if node.Kind != yaml.SequenceNode {
+				return ErrInvalidAccessorPath{AccessorPath: path.String(), Object: path[:i].String()}
+			}
+			item := selectNode(node, key)
+			if item == nil {
+				return ErrKeyNotFoundInObject{Key: path[:i+1].String(), Object: path[:i].String()}
+			}
+			node = item
+
+		default:
+			return ErrInvalidAccessorPath{AccessorPath: path.String(), Object: path[:i].String()}
+		}
+	}
+
+	if node.Kind != yaml.ScalarNode {
+		return ErrInvalidAccessorPath{AccessorPath: path.String(), Object: path.String()}
+	}
+	if _, found := e.modified[node]; !found && node.Line > 0 {
+		e.modified[node] = originalScalar{Value: node.Value, Style: node.Style}
+	}
+	setScalar(node, value, tag)
+	return nil
+}
+
+// insert adds the remainder of the path from index i to the given mapping
+func (e *yamlEditor) insert(mapping *yaml.Node, path yamlPath, i int, value, tag string) error {
+	valueNode := new(yaml.Node)
+	setScalar(valueNode, value, tag)
+	for j := len(path) - 1; j > i; j-- {
+		key, isKey := path[j].(string)
+		if !isKey {
+			return ErrKeyNotFoundInObject{Key: fmt.Sprint(path[i]), Object: path[:i].String()}
+		}
+		valueNode = &yaml.Node{
+			Kind:    yaml.MappingNode,
+			Tag:     "!!map",
+			Content: []*yaml.Node{newKeyNode(key), valueNode},
+		}
+	}
+
+	keyNode := newKeyNode(path[i].(string))
+	mapping.Content = append(mapping.Content, keyNode, valueNode)
+	if mapping.Line > 0 {
+		e.insertions = append(e.insertions, yamlInsertion{
+			Mapping: mapping,
+			Key:     keyNode,
+			Value:   valueNode,
+		})
+	}
+	return nil
+}
+
+// remove removes the key at the end of the path from its mapping, if the key exists
+func (e *yamlEditor) remove(node *yaml.Node, path yamlPath) error {
+	for i, element := range path {
+		switch key := element.(type) {
+		case string:
+			if isNull(node) {
+				return nil
+			}
+			if node.Kind != yaml.MappingNode {
+				return ErrInvalidAccessorPath{AccessorPath: path.String(), Object: path[:i].String()}
+			}
+			if i == len(path)-1 {
+				for j := 0; j+1 < len(node.Content); j += 2 {
+					if node.Content[j].Value != key {
+						continue
+					}
+					if node.Line > 0 {
+						e.removals = append(e.removals, yamlRemoval{
+							Mapping: node,
+							Key:     node.Content[j],
+							Value:   node.Content[j+1],
+						})
+					}
+					node.Content = append(node.Content[:j], node.Content[j+2:]...)
+					return nil
+				}
+				return nil
+			}
+			if node = mappingValue(node, key); node == nil {
+				return nil
+			}
+
+		case int:
+			if node.Kind != yaml.SequenceNode || i == len(path)-1 {
+				return ErrInvalidAccessorPath{AccessorPath: path.String(), Object: path[:i].String()}
+			}
+			if key < 0 || key >= len(node.Content) {
+				return ErrKeyNotFoundInObject{Key: fmt.Sprintf("[%d]", key), Object: path[:i].String()}
+			}
+			node = node.Content[key]
+
+		case sequenceSelector:
+			if node.Kind != yaml.SequenceNode || i == len(path)-1 {
+				return ErrInvalidAccessorPath{AccessorPath: path.String(), Object: path[:i].String()}
+			}
+			item := selectNode(node, key)
+			if item == nil {
+				return ErrKeyNotFoundInObject{Key: path[:i+1].String(), Object: path[:i].String()}
+			}
+			node = item
+
+		default:
+			return ErrInvalidAccessorPath{AccessorPath: path.String(), Object: path[:i].String()}
+		}
+	}
+	return nil
+}
+
+// patch patches all changes into the original text. It returns false if that is not possible.
+func (e *yamlEditor) patch() ([]byte, bool) {
+	if e.reencode {
+		return nil, false
+	}
+
+	var textEdits []textEdit
+	for node, original := range e.modified {
+		if node.Anchor != "" || original.Style&yaml.TaggedStyle != 0 {
+			return nil, false
+		}
+		start := e.offset(node.Line, node.Column)
+		end, ok := e.scalarEnd(start, original)
+		if !ok {
+			return nil, false
+		}
+		textEdits = append(textEdits, textEdit{
+			Start: start,
+			End:   end,
+			Text:  formatScalar(node.Value, node.Tag, original.Style),
+		})
+	}
+
+	for _, insertion := range e.insertions {
+		start, indent, ok := e.insertionPoint(insertion.Mapping)
+		if !ok {
+			return nil, false
+		}
+		text, err := encodeMappingEntry(insertion.Key, insertion.Value, indent)
+		if err != nil {
+			return nil, false
+		}
+		textEdits = append(textEdits, textEdit{
+			Start: start,
+			End:   start,
+			Text:  text,
+		})
+	}
+
+	for _, removal := range e.removals {
+		start, end, ok := e.entryLines(removal)
+		if !ok {
+			return nil, false
+		}
+		textEdits = append(textEdits, textEdit{
+			Start: start,
+			End:   end,
+		})
+	}
+
+	// apply edits from the end of the document so that earlier offsets stay valid
+	sort.SliceStable(textEdits, func(i, j int) bool {
+		return textEdits[i].Start < textEdits[j].Start
+	})
+	patchedData := append([]byte(nil), e.data...)
+	for i := len(textEdits) - 1; i >= 0; i-- {
+		patchedData = append(
+			patchedData[:textEdits[i].Start],
+			append([]byte(textEdits[i].Text), patchedData[textEdits[i].End:]...)...,
+		)
+	}
+	return patchedData, true
+}
+
+// offset returns the byte offset of the given 1 based line and character column
+func (e *yamlEditor) offset(line, column int) int {
+	offset := e.lineOffsets[line-1]
+	for c := 1; c < column && offset < len(e.data); c++ {
+		_, size := utf8.DecodeRune(e.data[offset:])
+		offset += size
+	}
+	return offset
+}
+
+// scalarEnd returns the offset of the end of the text of the scalar starting at start
+func (e *yamlEditor) scalarEnd(start int, original originalScalar) (int, bool) {
+	switch {
+	case original.Style&yaml.DoubleQuotedStyle != 0:
+		for i := start + 1; i < len(e.data); i++ {
+			switch e.data[i] {
+			case '\\':
+				i++
+			case '"':
+				return i + 1, true
+			}
+		}
+		return 0, false
+
+	case original.Style&yaml.SingleQuotedStyle != 0:
+		for i := start + 1; i < len(e.data); i++ {
+			if e.data[i] == '\'' {
+				if i+1 < len(e.data) && e.data[i+1] == '\'' {
+					i++
+					continue
+				}
+				return i + 1, true
+			}
+		}
+		return 0, false
+
+	case original.Style&(yaml.LiteralStyle|yaml.FoldedStyle|yaml.FlowStyle) != 0:
+		return 0, false
+
+	default:
+		// plain scalars may be folded over more than one line
+		var lines []string
+		lineStart := start
+		for lineStart < len(e.data) {
+			lineEnd := bytes.IndexByte(e.data[lineStart:], '\n')
+			if lineEnd == -1 {
+				lineEnd = len(e.data)
+			} else {
+				lineEnd += lineStart
+			}
+			line := string(e.data[lineStart:lineEnd])
+			if commentStart := strings.Index(line, " #"); commentStart != -1 {
+				line = line[:commentStart]
+			}
+			trimmedLine := strings.TrimSpace(line)
+			if trimmedLine == "" {
+				return 0, false
+			}
+			lines = append(lines, trimmedLine)
+			if strings.Join(lines, " ") == original.Value {
+				return lineStart + strings.Index(line, trimmedLine) + len(trimmedLine), true
+			}
+			if len(strings.Join(lines, " ")) >= len(original.Value) {
+				return 0, false
+			}
+			lineStart = lineEnd + 1
+		}
+		return 0, false
+	}
+}
+
+/*
+entryLines returns the offsets of the lines of the given removed mapping entry. The entry
+can only be removed from the text if it is a scalar value on a line of its own and the
+mapping is not left empty, which would turn it into a null value.
+*/
+func (e *yamlEditor) entryLines(removal yamlRemoval) (int, int, bool) {
+	key, value := removal.Key, removal.Value
+	if removal.Mapping.Style&yaml.FlowStyle != 0 || len(removal.Mapping.Content) == 0 {
+		return 0, 0, false
+	}
+	if value.Kind != yaml.ScalarNode || value.Anchor != "" || value.Line != key.Line {
+		return 0, 0, false
+	}
+	lineStart := e.lineOffsets[key.Line-1]
+	if len(bytes.TrimLeft(e.data[lineStart:e.offset(key.Line, key.Column)], " ")) != 0 {
+		return 0, 0, false
+	}
+	original, found := e.modified[value]
+	if !found {
+		original = originalScalar{Value: value.Value, Style: value.Style}
+	}
+	valueEnd, ok := e.scalarEnd(e.offset(value.Line, value.Column), original)
+	if !ok {
+		return 0, 0, false
+	}
+	lineEnd := bytes.IndexByte(e.data[valueEnd:], '\n')
+	if lineEnd == -1 {
+		lineEnd = len(e.data)
+	} else {
+		lineEnd += valueEnd + 1
+	}
+	if rest := strings.TrimSpace(string(e.data[valueEnd:lineEnd])); rest != "" && !strings.HasPrefix(rest, "#") {
+		return 0, 0, false
+	}
+	return lineStart, lineEnd, true
+}
+
+/*
+insertionPoint returns the offset at which keys can be added to the given mapping and
+the indentation they need. Keys are added on the line before the first key of the
+mapping which starts its own line.
+*/
+func (e *yamlEditor) insertionPoint(mapping *yaml.Node) (int, int, bool) {
+	if mapping.Style&yaml.FlowStyle != 0 {
+		return 0, 0, false
+	}
+	for i := 0; i < len(mapping.Content); i += 2 {
+		key := mapping.Content[i]
+		if key.Line == 0 {
+			continue
+		}
+		lineStart := e.lineOffsets[key.Line-1]
+		keyStart := e.offset(key.Line, key.Column)
+		if len(bytes.TrimLeft(e.data[lineStart:keyStart], " ")) == 0 {
+			return lineStart, key.Column - 1, true
+		}
+	}
+	return 0, 0, false
+}
+
+// encodeMappingEntry encodes a single key and value as block yaml indented by indent spaces
+func encodeMappingEntry(key, value *yaml.Node, indent int) (string, error) {
+	var encodedData bytes.Buffer
+	encoder := yaml.NewEncoder(&encodedData)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&yaml.Node{
+		Kind:    yaml.MappingNode,
+		Tag:     "!!map",
+		Content: []*yaml.Node{key, value},
+	}); err != nil {
+		return "", err
+	}
+	if err := encoder.Close(); err != nil {
+		return "", err
+	}
+
+	var text strings.Builder
+	for _, line := range strings.SplitAfter(encodedData.String(), "\n") {
+		if line != "" {
+			text.WriteString(strings.Repeat(" ", indent) + line)
+		}
+	}
+	return text.String(), nil
+}
+
+/*
+formatScalar formats a value as yaml in the given style, quoting it if necessary. Values
+which are not strings are written plain so that they keep their tag.
+*/
+func formatScalar(value, tag string, style yaml.Style) string {
+	switch {
+	case tag != strTag:
+		return value
+	case strings.ContainsAny(value, "\n\r\t"):
+		return doubleQuote(value)
+	case style&yaml.DoubleQuotedStyle != 0:
+		return doubleQuote(value)
+	case style&yaml.SingleQuotedStyle != 0:
+		return "'" + strings.Replace(value, "'", "''", -1) + "'"
+	case isPlainSafe(value):
+		return value
+	default:
+		return doubleQuote(value)
+	}
+}
+
+// doubleQuote quotes a value as a json string which is also a valid double quoted yaml scalar
+func doubleQuote(value string) string {
+	var quoted bytes.Buffer
+	encoder := json.NewEncoder(&quoted)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return fmt.Sprintf("%q", value)
+	}
+	return strings.TrimSuffix(quoted.String(), "\n")
+}
+
+// isPlainSafe returns true if the value is read back as the same string when written unquoted
+func isPlainSafe(value string) bool {
+	if value == "" || strings.ContainsAny(value, ",[]{}") || strings.TrimSpace(value) != value {
+		return false
+	}
+	var decoded map[string]interface{}
+	if err := yaml.Unmarshal([]byte("value: "+value), &decoded); err != nil {
+		return false
+	}
+	decodedValue, isString := decoded["value"].(string)
+	return isString && decodedValue == value
+}
+
+func setScalar(node *yaml.Node, value, tag string) {
+	node.Kind = yaml.ScalarNode
+	node.Tag = tag
+	node.Value = value
+	switch {
+	case tag != strTag:
+		node.Style = 0
+	case !isPlainSafe(value) && node.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) == 0:
+		node.Style = yaml.DoubleQuotedStyle
+	}
+}
+
+func newKeyNode(key string) *yaml.Node {
+	keyNode := new(yaml.Node)
+	setScalar(keyNode, key, strTag)
+	return keyNode
+}
+
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// selectNode returns the mapping in the sequence selected by the given selector
+func selectNode(sequence *yaml.Node, selector sequenceSelector) *yaml.Node {
+	for _, item := range sequence.Content {
+		if item.Kind != yaml.MappingNode {
+			continue
+		}
+		if value := mappingValue(item, selector.Key); value != nil && value.Kind == yaml.ScalarNode && value.Value == selector.Value {
+			return item
+		}
+	}
+	return nil
+}
+
+func isNull(node *yaml.Node) bool {
+	return node.Kind == yaml.ScalarNode && node.Tag == "!!null"
+}
@@ -0,0 +1,278 @@
+package manifest
+
+import (
+	"gopkg.in/yaml.v3"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func strEdit(value string, path ...interface{}) yamlEdit {
+	return yamlEdit{Path: path, Value: value, Tag: strTag}
+}
+
+func TestApplyYAMLEditsInPlace(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		edits    []yamlEdit
+		want     string
+	}{
+		{
+			name:     "plain scalar",
+			document: "image: api:1.0.0\nname: api\n",
+			edits:    []yamlEdit{strEdit("api:2.0.0", "image")},
+			want:     "image: api:2.0.0\nname: api\n",
+		},
+		{
+			name:     "plain scalar with comment",
+			document: "image: api:1.0.0 # deployed by kubernite\nname: api\n",
+			edits:    []yamlEdit{strEdit("api:2.0.0", "image")},
+			want:     "image: api:2.0.0 # deployed by kubernite\nname: api\n",
+		},
+		{
+			name:     "plain scalar folded over lines",
+			document: "description: a long\n  description\nname: api\n",
+			edits:    []yamlEdit{strEdit("short", "description")},
+			want:     "description: short\nname: api\n",
+		},
+		{
+			name:     "double quoted scalar",
+			document: "tag: \"1.0\" # quoted\n",
+			edits:    []yamlEdit{strEdit("2.0", "tag")},
+			want:     "tag: \"2.0\" # quoted\n",
+		},
+		{
+			name:     "double quoted scalar with escapes",
+			document: "message: \"say \\\"hi\\\"\"\nname: api\n",
+			edits:    []yamlEdit{strEdit("bye", "message")},
+			want:     "message: \"bye\"\nname: api\n",
+		},
+		{
+			name:     "single quoted scalar",
+			document: "tag: 'it''s 1.0'\n",
+			edits:    []yamlEdit{strEdit("it's 2.0", "tag")},
+			want:     "tag: 'it''s 2.0'\n",
+		},
+		{
+			name:     "plain string which needs quoting",
+			document: "enabled: yes please\n",
+			edits:    []yamlEdit{strEdit("true", "enabled")},
+			want:     "enabled: \"true\"\n",
+		},
+		{
+			name:     "typed value replaces quoted string",
+			document: "replicas: \"1\" # scaled\n",
+			edits:    []yamlEdit{{Path: yamlPath{"replicas"}, Value: "3", Tag: "!!int"}},
+			want:     "replicas: 3 # scaled\n",
+		},
+		{
+			name:     "sequence index",
+			document: "containers:\n  - name: api\n    image: api:1\n  - name: proxy\n    image: proxy:1\n",
+			edits:    []yamlEdit{strEdit("proxy:2", "containers", 1, "image")},
+			want:     "containers:\n  - name: api\n    image: api:1\n  - name: proxy\n    image: proxy:2\n",
+		},
+		{
+			name:     "sequence selector",
+			document: "containers:\n  - name: api\n    image: api:1\n  - name: proxy\n    image: proxy:1\n",
+			edits:    []yamlEdit{strEdit("api:2", "containers", sequenceSelector{Key: "name", Value: "api"}, "image")},
+			want:     "containers:\n  - name: api\n    image: api:2\n  - name: proxy\n    image: proxy:1\n",
+		},
+		{
+			name:     "key inserted into block mapping",
+			document: "metadata:\n  # the name\n  name: api\n",
+			edits:    []yamlEdit{strEdit("v1", "metadata", "labels", "version")},
+			want:     "metadata:\n  # the name\n  labels:\n    version: v1\n  name: api\n",
+		},
+		{
+			name:     "key inserted into block mapping in sequence",
+			document: "containers:\n  - name: api\n    image: api:1\n",
+			edits:    []yamlEdit{strEdit("/app", "containers", 0, "workingDir")},
+			want:     "containers:\n  - name: api\n    workingDir: /app\n    image: api:1\n",
+		},
+		{
+			name:     "key removed",
+			document: "images:\n  - name: api\n    newTag: 1.0.0\n    digest: sha256:abc # pinned\n",
+			edits:    []yamlEdit{{Path: yamlPath{"images", 0, "digest"}, Remove: true}},
+			want:     "images:\n  - name: api\n    newTag: 1.0.0\n",
+		},
+		{
+			name:     "missing key removed",
+			document: "images:\n  - name: api\n    newTag: 1.0.0\n",
+			edits:    []yamlEdit{{Path: yamlPath{"images", 0, "digest"}, Remove: true}},
+			want:     "images:\n  - name: api\n    newTag: 1.0.0\n",
+		},
+		{
+			name:     "several edits",
+			document: "a: 1\nb:\n  c: x\n",
+			edits: []yamlEdit{
+				strEdit("2", "a"),
+				strEdit("y", "b", "c"),
+				strEdit("z", "b", "d"),
+			},
+			want: "a: \"2\"\nb:\n  d: z\n  c: y\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			editedData, err := applyYAMLEdits([]byte(test.document), test.edits)
+			if err != nil {
+				t.Fatalf("applyYAMLEdits returned error: %s", err)
+			}
+			if string(editedData) != test.want {
+				t.Errorf("applyYAMLEdits =\n%s\nwant\n%s", editedData, test.want)
+			}
+		})
+	}
+}
+
+func TestApplyYAMLEditsReencoded(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		edits    []yamlEdit
+		want     map[string]interface{}
+	}{
+		{
+			name:     "block folded scalar",
+			document: "description: >-\n  a long\n  description\nname: api\n",
+			edits:    []yamlEdit{strEdit("short", "description")},
+			want:     map[string]interface{}{"description": "short", "name": "api"},
+		},
+		{
+			name:     "literal scalar",
+			document: "script: |\n  echo hi\n",
+			edits:    []yamlEdit{strEdit("echo bye", "script")},
+			want:     map[string]interface{}{"script": "echo bye"},
+		},
+		{
+			name:     "key inserted into flow mapping",
+			document: "labels: {app: api}\n",
+			edits:    []yamlEdit{strEdit("v1", "labels", "version")},
+			want:     map[string]interface{}{"labels": map[string]interface{}{"app": "api", "version": "v1"}},
+		},
+		{
+			name:     "key inserted into null mapping",
+			document: "metadata:\n  annotations:\n  name: api\n",
+			edits:    []yamlEdit{strEdit("initial deploy", "metadata", "annotations", "kubernetes.io/change-cause")},
+			want: map[string]interface{}{"metadata": map[string]interface{}{
+				"annotations": map[string]interface{}{"kubernetes.io/change-cause": "initial deploy"},
+				"name":        "api",
+			}},
+		},
+		{
+			name:     "key removed from flow mapping",
+			document: "image: {name: api, digest: sha256:abc}\n",
+			edits:    []yamlEdit{{Path: yamlPath{"image", "digest"}, Remove: true}},
+			want:     map[string]interface{}{"image": map[string]interface{}{"name": "api"}},
+		},
+		{
+			name:     "only key removed",
+			document: "image:\n  digest: sha256:abc\nname: api\n",
+			edits:    []yamlEdit{{Path: yamlPath{"image", "digest"}, Remove: true}},
+			want:     map[string]interface{}{"image": map[string]interface{}{}, "name": "api"},
+		},
+		{
+			name:     "anchored scalar",
+			document: "tag: &tag 1.0.0\nother: *tag\n",
+			edits:    []yamlEdit{strEdit("2.0.0", "tag")},
+			want:     map[string]interface{}{"tag": "2.0.0", "other": "2.0.0"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			editedData, err := applyYAMLEdits([]byte(test.document), test.edits)
+			if err != nil {
+				t.Fatalf("applyYAMLEdits returned error: %s", err)
+			}
+			var got map[string]interface{}
+			if err := yaml.Unmarshal(editedData, &got); err != nil {
+				t.Fatalf("edited document is invalid: %s\n%s", err, editedData)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("applyYAMLEdits =\n%s\ndecoded %v, want %v", editedData, got, test.want)
+			}
+		})
+	}
+}
+
+func TestApplyYAMLEditsKeepsComments(t *testing.T) {
+	document := "# deployment\nlabels: {app: api} # flow\nname: api # the name\n"
+	editedData, err := applyYAMLEdits([]byte(document), []yamlEdit{strEdit("v1", "labels", "version")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, comment := range []string{"# deployment", "# flow", "# the name"} {
+		if !strings.Contains(string(editedData), comment) {
+			t.Errorf("comment %q lost in\n%s", comment, editedData)
+		}
+	}
+}
+
+func TestApplyYAMLEditsInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		document string
+		edit     yamlEdit
+	}{
+		{
+			name:     "key of a scalar",
+			document: "image: api:1\n",
+			edit:     strEdit("x", "image", "tag"),
+		},
+		{
+			name:     "index of a mapping",
+			document: "containers:\n  name: api\n",
+			edit:     strEdit("x", "containers", 0, "image"),
+		},
+		{
+			name:     "index out of range",
+			document: "containers:\n  - name: api\n",
+			edit:     strEdit("x", "containers", 1, "image"),
+		},
+		{
+			name:     "missing selected element",
+			document: "containers:\n  - name: api\n",
+			edit:     strEdit("x", "containers", sequenceSelector{Key: "name", Value: "proxy"}, "image"),
+		},
+		{
+			name:     "mapping replaced by scalar",
+			document: "metadata:\n  name: api\n",
+			edit:     strEdit("x", "metadata"),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if editedData, err := applyYAMLEdits([]byte(test.document), []yamlEdit{test.edit}); err == nil {
+				t.Errorf("applyYAMLEdits returned no error and\n%s", editedData)
+			}
+		})
+	}
+}
+
+func TestParseScalar(t *testing.T) {
+	tests := []struct {
+		value string
+		want  yamlScalar
+	}{
+		{value: "3", want: yamlScalar{Text: "3", Value: int64(3), Tag: "!!int"}},
+		{value: "-7", want: yamlScalar{Text: "-7", Value: int64(-7), Tag: "!!int"}},
+		{value: "1.5", want: yamlScalar{Text: "1.5", Value: 1.5, Tag: "!!float"}},
+		{value: "false", want: yamlScalar{Text: "false", Value: false, Tag: "!!bool"}},
+		{value: "null", want: yamlScalar{Text: "null", Value: nil, Tag: "!!null"}},
+		{value: "api:1.0.0", want: yamlScalar{Text: "api:1.0.0", Value: "api:1.0.0", Tag: strTag}},
+		{value: "", want: yamlScalar{Text: "", Value: "", Tag: strTag}},
+		{value: `"3"`, want: yamlScalar{Text: "3", Value: "3", Tag: strTag}},
+		{value: "'true'", want: yamlScalar{Text: "true", Value: "true", Tag: strTag}},
+		{value: "3 # comment", want: yamlScalar{Text: "3 # comment", Value: "3 # comment", Tag: strTag}},
+		{value: "a: b", want: yamlScalar{Text: "a: b", Value: "a: b", Tag: strTag}},
+		{value: ".inf", want: yamlScalar{Text: ".inf", Value: ".inf", Tag: strTag}},
+	}
+	for _, test := range tests {
+		t.Run(test.value, func(t *testing.T) {
+			if got := parseScalar(test.value); !reflect.DeepEqual(got, test.want) {
+				t.Errorf("parseScalar(%q) = %#v, want %#v", test.value, got, test.want)
+			}
+		})
+	}
+}